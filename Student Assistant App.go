@@ -1,115 +1,259 @@
 package main
 
 import (
+	"Student-Assistant-App/src/cli"
+	"Student-Assistant-App/src/clock"
+	"Student-Assistant-App/src/config"
 	"Student-Assistant-App/src/controller"
+	"Student-Assistant-App/src/data/migrations"
 	"Student-Assistant-App/src/data/repository"
-	"Student-Assistant-App/src/middleware"
+	"Student-Assistant-App/src/health"
+	"Student-Assistant-App/src/keys"
+	"Student-Assistant-App/src/logging"
+	"Student-Assistant-App/src/openapi"
+	"Student-Assistant-App/src/router"
+	"Student-Assistant-App/src/server"
 	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/session"
+	"Student-Assistant-App/src/tokens"
+	"Student-Assistant-App/src/tracing"
+	"Student-Assistant-App/src/worker"
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	// The command comes before or after the config flags, e.g.
+	// "user list --role ADMIN" or "-config app.yaml user list".
+	args := os.Args[1:]
+	command := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	if command == "help" {
+		fmt.Println(cli.Usage)
+		return
 	}
 
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		log.Fatal("MONGO_URI not set in .env file")
+	cfg, args, err := config.Load(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if command == "" && len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	switch command {
+	case "":
+		command = "serve"
+	case "serve", "migrate", "user", "otp":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", command, cli.Usage)
+		os.Exit(2)
+	}
+
+	logger := logging.New(cfg.Log)
+	slog.SetDefault(logger)
+	fatal := func(msg string, err error) {
+		logger.Error(msg, "error", err)
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("failed to set up tracing", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed to flush traces", "error", err)
+		}
+	}()
 
-	client, err := mongo.NewClient(options.Client().ApplyURI(mongoURI))
+	client, err := mongo.NewClient(options.Client().ApplyURI(cfg.Mongo.URI).SetMonitor(otelmongo.NewMonitor()))
 	if err != nil {
-		log.Fatalf("Failed to create MongoDB client: %v", err)
+		fatal("failed to create MongoDB client", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := client.Connect(ctx); err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		fatal("failed to connect to MongoDB", err)
 	}
 
 	defer func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		if err := client.Disconnect(ctx); err != nil {
-			log.Printf("Error disconnecting MongoDB: %v", err)
+			logger.Error("failed to disconnect MongoDB", "error", err)
 		}
 	}()
 
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		log.Fatal("DB_NAME not set in .env file")
+	db := client.Database(cfg.Mongo.Database)
+	migrationRunner := migrations.NewRunner(db, migrations.All(cfg, logger), logger)
+
+	if command == "migrate" {
+		if err := cli.Migrate(context.Background(), migrationRunner, args, os.Stdout, os.Stderr); err != nil {
+			fatal("migration failed", err)
+		}
+		return
 	}
-	db := client.Database(dbName)
 
 	userRepo := repository.NewUserRepositoryImpl(db)
 	otpRepo := repository.NewOTPRepositoryImpl(db)
+	institutionRepo := repository.NewInstitutionRepositoryImpl(db)
+	invitationRepo := repository.NewInvitationRepositoryImpl(db)
+	auditRepo := repository.NewAuditRepositoryImpl(db)
+	identityRepo := repository.NewIdentityRepositoryImpl(db)
+	signingKeyRepo := repository.NewSigningKeyRepositoryImpl(db)
+	apiKeyRepo := repository.NewAPIKeyRepositoryImpl(db)
 
-	emailService, err := service.NewEmailService()
+	keyStore, err := keys.NewStore(signingKeyRepo, cfg.JWT, logger)
 	if err != nil {
-		log.Fatalf("Failed to initialize email service: %v", err)
+		fatal("failed to load JWT signing keys", err)
 	}
+	tokenManager := tokens.NewManager(cfg.JWT, keyStore)
+	mailer := service.NewEmailService(cfg.Email, logger)
+	signupPolicy := service.NewSignupPolicy(institutionRepo, cfg.Signup)
+	userService := service.NewUserServiceImpl(userRepo, auditRepo, signupPolicy, cfg.Signup.DefaultRole, tokenManager, logger)
 
-	otpService := service.NewOTPService(otpRepo, emailService)
-	userService := service.NewUserServiceImpl(userRepo)
-	authService := service.NewAuthService(userService)
-
-	userController := controller.NewUserController(userService, authService, otpService, emailService)
-
-	router := gin.Default()
+	if command != "serve" {
+		// Commands run before migrations, key rotation and bootstrapping,
+		// so they never change the database beyond what they were asked
+		// to do. They exit before the outbox worker starts, so they send
+		// email directly.
+		commandCtx := context.Background()
+		if err := keyStore.Load(commandCtx); err != nil {
+			fatal("failed to load JWT signing keys", err)
+		}
+		admin := cli.New(userService, service.NewOTPService(otpRepo, mailer, cfg.OTP, clock.System, logger), os.Stdin, os.Stdout, os.Stderr)
+		if err := admin.Run(commandCtx, command, args); err != nil {
+			fatal(command+" command failed", err)
+		}
+		return
+	}
 
-	public := router.Group("/api")
-	{
-		public.POST("/auth/signup", userController.Signup)
-		public.POST("/auth/login", userController.Login)
-		public.POST("/auth/send-otp", userController.SendOTP)
-		public.POST("/auth/verify-otp", userController.VerifyOTP)
-		public.POST("/auth/resend-otp", userController.ResendOTP)
-		public.POST("/auth/signup-with-otp", userController.SignupWithOTP)
-		public.POST("/auth/login-with-otp", userController.LoginWithOTP)
+	if cfg.Mongo.AutoMigrate {
+		// Migrations may take longer than connecting, so they do not share
+		// its timeout.
+		applied, err := migrationRunner.Up(context.Background())
+		if err != nil {
+			fatal("failed to apply migrations", err)
+		}
+		if applied > 0 {
+			logger.Info("applied migrations", "count", applied)
+		}
 	}
 
-	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware())
-	{
-		api.GET("/users/me", userController.GetCurrentUser)
-		api.GET("/users/:id", userController.GetUser)
-		api.PUT("/users/:id", userController.UpdateUser)
-		api.DELETE("/users/:id", userController.DeleteUser)
+	startupCtx, cancelStartup := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelStartup()
+	if err := keyStore.Refresh(startupCtx); err != nil {
+		fatal("failed to load JWT signing keys", err)
+	}
+	sessions := session.New(cfg.Session, cfg.JWT)
+	emailService := service.NewEmailOutbox(mailer, cfg.Email.OutboxSize, logger)
+	otpService := service.NewOTPService(otpRepo, emailService, cfg.OTP, clock.System, logger)
+	if err := userService.BootstrapAdmin(startupCtx, cfg.Bootstrap); err != nil {
+		fatal("failed to bootstrap admin", err)
+	}
 
-		admin := api.Group("/admin")
-		admin.Use(middleware.AdminMiddleware())
-		{
-			admin.GET("/users", userController.GetAllUsers)
-		}
+	authService := service.NewAuthService(userService, tokenManager, logger)
+	oidcService := service.NewOIDCService(identityRepo, userService, authService, cfg.OIDC, logger)
+	institutionService := service.NewInstitutionService(institutionRepo, userRepo, logger)
+	if err := institutionService.EnsureInstitutions(startupCtx, cfg.Signup.Institutions); err != nil {
+		fatal("failed to create configured institutions", err)
 	}
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, auditRepo, cfg.APIKeys, logger)
+	invitationService := service.NewInvitationService(invitationRepo, institutionRepo, userService, signupPolicy, emailService, cfg.Invitations, logger)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	userController := controller.NewUserController(userService, authService, otpService, emailService, emailService, sessions)
+	institutionController := controller.NewInstitutionController(institutionService)
+	invitationController := controller.NewInvitationController(invitationService, sessions)
+	oidcController := controller.NewOIDCController(oidcService, cfg.JWT.Secret, cfg.OIDC.FrontendURL, sessions)
+	keyController := controller.NewKeyController(keyStore)
+	docsController, err := controller.NewDocsController(openapi.Build(openapi.Operations()), cfg.Docs)
+	if err != nil {
+		fatal("failed to build the OpenAPI document", err)
 	}
+	apiKeyController := controller.NewAPIKeyController(apiKeyService)
+
+	readiness := &server.Readiness{}
+	healthRegistry := health.NewRegistry(cfg.Health.CheckTimeout, cfg.Health.CacheTTL)
+	healthRegistry.Register(
+		health.CheckerFunc("config", func(ctx context.Context) error {
+			return cfg.Validate()
+		}),
+		health.CheckerFunc("mongo", func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		}),
+		health.CheckerFunc("email", emailService.CheckConnection),
+	)
+	healthController := controller.NewHealthController(healthRegistry, readiness)
 
+	engine := router.New(router.Dependencies{
+		Config:             cfg,
+		Logger:             logger,
+		TokenManager:       tokenManager,
+		APIKeyService:      apiKeyService,
+		InstitutionService: institutionService,
+		Sessions:           sessions,
+	}, router.Controllers{
+		User:        userController,
+		Institution: institutionController,
+		Invitation:  invitationController,
+		OIDC:        oidcController,
+		APIKey:      apiKeyController,
+		Key:         keyController,
+		Docs:        docsController,
+		Health:      healthController,
+	})
+
+	workers := worker.NewGroup(logger)
+	workers.Go("email-outbox", emailService.Run)
+	workers.Go("otp-cleanup", worker.Every(cfg.OTP.CleanupInterval, logger, func(ctx context.Context) error {
+		_, err := otpService.PurgeOTPs(ctx, "")
+		return err
+	}))
+	workers.Go("jwt-keys", worker.Every(cfg.JWT.KeyRefreshInterval, logger, keyStore.Refresh))
+
+	srv := server.New(cfg.Server, engine, readiness, logger)
+	serverErr := make(chan error, 1)
 	go func() {
-		log.Printf("Starting server on :%s", port)
-		if err := router.Run(":" + port); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
-		}
+		serverErr <- srv.Start()
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutdown signal received, exiting...")
+	select {
+	case <-quit:
+		logger.Info("shutdown signal received, draining")
+	case err := <-serverErr:
+		if err != nil {
+			logger.Error("server stopped unexpectedly", "error", err)
+		}
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server did not shut down cleanly", "error", err)
+	}
+	if err := workers.Stop(shutdownCtx); err != nil {
+		logger.Error("background workers did not stop in time", "error", err)
+	}
+	logger.Info("shutdown complete")
 }
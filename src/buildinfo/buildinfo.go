@@ -0,0 +1,48 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Version and Commit are set at build time with
+//
+//	go build -ldflags "-X Student-Assistant-App/src/buildinfo.Version=v1.2.3 -X Student-Assistant-App/src/buildinfo.Commit=abc123"
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+var StartTime = time.Now()
+
+type Info struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	GoVersion string    `json:"go_version"`
+	StartTime time.Time `json:"start_time"`
+	Uptime    string    `json:"uptime"`
+}
+
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		StartTime: StartTime,
+		Uptime:    time.Since(StartTime).Round(time.Second).String(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = build.GoVersion
+		if info.Commit == "" {
+			for _, setting := range build.Settings {
+				if setting.Key == "vcs.revision" {
+					info.Commit = setting.Value
+				}
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"Student-Assistant-App/src/data/migrations"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrate implements "migrate up", "migrate down [steps]" and
+// "migrate status". It needs only the database, so it runs before the
+// services are built.
+func Migrate(ctx context.Context, runner *migrations.Runner, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [steps] | status")
+	}
+
+	flagSet := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	output := flagSet.String("output", "table", "output format: table or json")
+	if err := flagSet.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up(ctx)
+		if renderErr := renderCount(stdout, *output, "applied", applied); err == nil {
+			err = renderErr
+		}
+		return err
+	case "down":
+		steps := 1
+		if flagSet.NArg() > 0 {
+			n, err := strconv.Atoi(flagSet.Arg(0))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", flagSet.Arg(0))
+			}
+			steps = n
+		}
+		reverted, err := runner.Down(ctx, steps)
+		if renderErr := renderCount(stdout, *output, "reverted", reverted); err == nil {
+			err = renderErr
+		}
+		return err
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		t := table{header: []string{"VERSION", "STATUS", "APPLIED AT", "DESCRIPTION"}}
+		for _, status := range statuses {
+			state, appliedAt := "pending", "-"
+			if status.Applied {
+				state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+			}
+			t.rows = append(t.rows, []string{strconv.Itoa(status.Version), state, appliedAt, status.Description})
+		}
+		return render(stdout, *output, statuses, t)
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+// renderCount reports how many migrations a step applied or reverted, even
+// when it stopped on an error part way.
+func renderCount(w io.Writer, format, label string, count int) error {
+	return render(w, format, map[string]int{label: count}, table{
+		header: []string{strings.ToUpper(label)},
+		rows:   [][]string{{strconv.Itoa(count)}},
+	})
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+)
+
+// purgeOTPs deletes expired codes, or every code of one user with --email.
+func (c *CLI) purgeOTPs(ctx context.Context, args []string) error {
+	flagSet, output := c.flagSet("otp purge")
+	email := flagSet.String("email", "", "delete every code of this user instead of expired codes")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	deleted, err := c.otpService.PurgeOTPs(ctx, *email)
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, *output, map[string]int64{"deleted": deleted}, table{
+		header: []string{"DELETED"},
+		rows:   [][]string{{strconv.FormatInt(deleted, 10)}},
+	})
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/service"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (c *CLI) createUser(ctx context.Context, args []string) error {
+	flagSet, output := c.flagSet("user create")
+	email := flagSet.String("email", "", "email address")
+	name := flagSet.String("name", "", "display name")
+	role := flagSet.String("role", string(enums.User), "role: ADMIN, INSTITUTION_ADMIN, USER or GUEST")
+	password := flagSet.String("password", "", "password (visible to other local users; prefer --password-stdin)")
+	passwordStdin := flagSet.Bool("password-stdin", false, "read the password from standard input")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	secret, err := c.password(*password, *passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	createUserRequest := request.CreateUserRequest{}
+	createUserRequest.SetName(*name)
+	createUserRequest.SetEmail(*email)
+	createUserRequest.SetPassword(secret)
+	// No token is issued: CLI commands only load the signing keys, and a
+	// fresh database has none yet.
+	user, err := c.userService.CreateAccount(ctx, &createUserRequest, enums.Role(strings.ToUpper(*role)))
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, *output, user, userTable(user))
+}
+
+func (c *CLI) setRole(ctx context.Context, args []string) error {
+	flagSet, output := c.flagSet("user set-role")
+	email := flagSet.String("email", "", "email address of the user")
+	id := flagSet.String("id", "", "ID of the user")
+	role := flagSet.String("role", "", "role: ADMIN, INSTITUTION_ADMIN, USER or GUEST")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := c.findUser(ctx, *email, *id)
+	if err != nil {
+		return err
+	}
+	user, err = c.userService.SetUserRole(ctx, Actor, user.ID.Hex(), enums.Role(strings.ToUpper(*role)))
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, *output, user, userTable(user))
+}
+
+// resetPassword sets a new password, or emails the user a password reset
+// code with --send-code.
+func (c *CLI) resetPassword(ctx context.Context, args []string) error {
+	flagSet, output := c.flagSet("user reset-password")
+	email := flagSet.String("email", "", "email address of the user")
+	id := flagSet.String("id", "", "ID of the user")
+	password := flagSet.String("password", "", "new password (visible to other local users; prefer --password-stdin)")
+	passwordStdin := flagSet.Bool("password-stdin", false, "read the new password from standard input")
+	sendCode := flagSet.Bool("send-code", false, "email the user a password reset code instead")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := c.findUser(ctx, *email, *id)
+	if err != nil {
+		return err
+	}
+
+	if *sendCode {
+		if *password != "" || *passwordStdin {
+			return errors.New("--send-code cannot be combined with a password")
+		}
+		if err := c.otpService.GenerateAndSendOTP(ctx, user.Email, "password_reset"); err != nil {
+			return err
+		}
+		return renderPasswordReset(c.stdout, *output, user.Email, "code_sent")
+	}
+
+	secret, err := c.password(*password, *passwordStdin)
+	if err != nil {
+		return err
+	}
+	if err := c.userService.SetPassword(ctx, user.Email, secret); err != nil {
+		return err
+	}
+	return renderPasswordReset(c.stdout, *output, user.Email, "password_set")
+}
+
+func renderPasswordReset(w io.Writer, format, email, result string) error {
+	return render(w, format, map[string]string{"email": email, "result": result}, table{
+		header: []string{"EMAIL", "RESULT"},
+		rows:   [][]string{{email, result}},
+	})
+}
+
+func (c *CLI) listUsers(ctx context.Context, args []string) error {
+	flagSet, output := c.flagSet("user list")
+	role := flagSet.String("role", "", "only users with this role")
+	institutionID := flagSet.String("institution", "", "only users of this institution")
+	search := flagSet.String("search", "", "match name or email")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	filter := repository.UserFilter{
+		Role:          enums.Role(strings.ToUpper(*role)),
+		InstitutionID: *institutionID,
+		Search:        *search,
+	}
+	if filter.Role != "" && !filter.Role.IsValid() {
+		return fmt.Errorf("invalid role %q", *role)
+	}
+	users, err := c.userService.FindUsers(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, *output, users, userTable(users...))
+}
+
+// findUser looks a user up by email or ID; exactly one must be given.
+func (c *CLI) findUser(ctx context.Context, email, id string) (*model.User, error) {
+	var user *model.User
+	var err error
+	switch {
+	case email != "" && id != "":
+		return nil, errors.New("give either --email or --id, not both")
+	case email != "":
+		user, err = c.userService.GetUserByEmail(ctx, email)
+	case id != "":
+		user, err = c.userService.GetUserByID(ctx, id)
+	default:
+		return nil, errors.New("--email or --id is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, service.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// password returns the flag value or the first line of standard input.
+func (c *CLI) password(value string, fromStdin bool) (string, error) {
+	switch {
+	case value != "" && fromStdin:
+		return "", errors.New("give either --password or --password-stdin, not both")
+	case fromStdin:
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading password: %w", err)
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	if value == "" {
+		return "", errors.New("a password is required (--password or --password-stdin)")
+	}
+	return value, nil
+}
+
+func userTable(users ...*model.User) table {
+	t := table{header: []string{"ID", "EMAIL", "NAME", "ROLE", "INSTITUTION"}}
+	for _, user := range users {
+		t.rows = append(t.rows, []string{user.ID.Hex(), user.Email, user.Name, string(user.Role), user.InstitutionID})
+	}
+	return t
+}
@@ -0,0 +1,881 @@
+package config
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the complete runtime configuration of the application. Values
+// are resolved in order of increasing precedence: defaults, an optional
+// YAML or TOML file, environment variables (including .env) and flags.
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Mongo       MongoConfig       `yaml:"mongo"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	OTP         OTPConfig         `yaml:"otp"`
+	Email       EmailConfig       `yaml:"email"`
+	Health      HealthConfig      `yaml:"health"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Signup      SignupConfig      `yaml:"signup"`
+	Tenancy     TenancyConfig     `yaml:"tenancy"`
+	Invitations InvitationsConfig `yaml:"invitations"`
+	Bootstrap   BootstrapConfig   `yaml:"bootstrap"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+	APIKeys     APIKeysConfig     `yaml:"api_keys"`
+	Session     SessionConfig     `yaml:"session"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Security    SecurityConfig    `yaml:"security"`
+	Deprecation DeprecationConfig `yaml:"deprecation"`
+	Docs        DocsConfig        `yaml:"docs"`
+}
+
+type ServerConfig struct {
+	Port              string        `yaml:"port"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// DrainDelay is how long the server keeps serving after readiness flips
+	// to unavailable, giving load balancers time to stop routing to it.
+	DrainDelay      time.Duration `yaml:"drain_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type MongoConfig struct {
+	URI      string `yaml:"uri"`
+	Database string `yaml:"database"`
+	// AutoMigrate applies pending schema migrations when the server starts.
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+type JWTConfig struct {
+	// Secret verifies legacy HS256 tokens and signs cookies.
+	Secret string        `yaml:"secret"`
+	TTL    time.Duration `yaml:"ttl"`
+	// Algorithm is used for new signing keys: RS256, ES256 or EdDSA.
+	Algorithm string `yaml:"algorithm"`
+	Issuer    string `yaml:"issuer"`
+	Audience  string `yaml:"audience"`
+	// RotationInterval is how long a signing key signs before a new one
+	// takes over. Retired keys keep verifying until their tokens expire.
+	RotationInterval time.Duration `yaml:"rotation_interval"`
+	// KeyRefreshInterval is how often instances reload keys and check
+	// whether rotation is due. New keys are published this long before use.
+	KeyRefreshInterval time.Duration `yaml:"key_refresh_interval"`
+	// KeyEncryptionKey encrypts signing keys stored in the database.
+	// Changing it makes stored keys unreadable, so new ones are created and
+	// earlier tokens stop validating.
+	KeyEncryptionKey string `yaml:"key_encryption_key"`
+	// AcceptHS256 keeps tokens signed with Secret valid while they drain
+	// after upgrading from HS256. They carry no nbf, iss or aud.
+	// Enable it only for the transition: anyone who knows Secret can mint
+	// such tokens.
+	AcceptHS256 bool `yaml:"accept_hs256"`
+	// Leeway tolerates clock skew when checking exp, nbf and iat.
+	Leeway time.Duration `yaml:"leeway"`
+}
+
+type OTPConfig struct {
+	Secret          string        `yaml:"secret"`
+	TTL             time.Duration `yaml:"ttl"`
+	ResendInterval  time.Duration `yaml:"resend_interval"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+	// InviteTTL is how long set-password codes sent to invited or imported
+	// users stay valid.
+	InviteTTL time.Duration `yaml:"invite_ttl"`
+	// MaxAttempts is how many codes may be tried against one OTP before it
+	// is used up and a new one has to be requested.
+	MaxAttempts int `yaml:"max_attempts"`
+}
+
+type EmailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+	// OutboxSize is the number of emails that may be queued for background
+	// delivery before senders fall back to delivering inline.
+	OutboxSize int `yaml:"outbox_size"`
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout"`
+	CacheTTL     time.Duration `yaml:"cache_ttl"`
+}
+
+type LogConfig struct {
+	// Level is one of debug, info, warn or error.
+	Level string `yaml:"level"`
+	// Format is json or text.
+	Format string `yaml:"format"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector URL. When empty the standard
+	// OTEL_EXPORTER_OTLP_* environment variables apply.
+	Endpoint    string  `yaml:"endpoint"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type SignupConfig struct {
+	// Institutions are created at startup if they do not exist yet. Existing
+	// institutions, and any others, are managed through the admin API, so
+	// editing an entry here does not change one that already exists. While
+	// no institutions exist, any domain is accepted and users are not
+	// assigned an institution.
+	Institutions []InstitutionConfig `yaml:"institutions"`
+	// BlockDisposable rejects addresses from throwaway-mail providers.
+	BlockDisposable bool `yaml:"block_disposable"`
+	// DisposableDomains extends the built-in list of throwaway-mail domains.
+	DisposableDomains []string `yaml:"disposable_domains"`
+	// DefaultRole is assigned to every new account; USER or GUEST.
+	DefaultRole enums.Role `yaml:"default_role"`
+}
+
+// BootstrapConfig creates the first global admin. On start, if no admin
+// exists yet, an account with AdminEmail and AdminPassword is created
+// outside every institution. Startup fails if the email is already taken by
+// a non-admin account. Once any admin exists it has no effect.
+type BootstrapConfig struct {
+	AdminEmail    string `yaml:"admin_email"`
+	AdminPassword string `yaml:"admin_password"`
+	AdminName     string `yaml:"admin_name"`
+}
+
+// TenancyConfig controls how requests are attributed to an institution.
+// Authenticated requests use the institution in the token; the header and
+// subdomain are consulted for public endpoints and for global admins.
+type TenancyConfig struct {
+	// Header names the request header carrying an institution ID.
+	Header string `yaml:"header"`
+	// BaseDomain, when set, resolves "<institution>.<base domain>" hosts to
+	// that institution.
+	BaseDomain string `yaml:"base_domain"`
+}
+
+type InvitationsConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+	// AcceptURL is the frontend page that accepts invitations; the token is
+	// appended as the "token" query parameter.
+	AcceptURL string `yaml:"accept_url"`
+}
+
+// SessionConfig selects how browsers hold their token. In "cookie" mode,
+// login and signup set an HttpOnly session cookie instead of returning the
+// token, plus a CSRF cookie whose value must be echoed in CSRFHeader on
+// state-changing requests. "bearer" mode returns the token in the body.
+type SessionConfig struct {
+	Mode         string `yaml:"mode"`
+	CookieName   string `yaml:"cookie_name"`
+	CookieDomain string `yaml:"cookie_domain"`
+	CookieSecure bool   `yaml:"cookie_secure"`
+	// SameSite is lax, strict or none; none requires CookieSecure and is
+	// only needed when the frontend is on another site.
+	SameSite       string `yaml:"same_site"`
+	CSRFCookieName string `yaml:"csrf_cookie_name"`
+	CSRFHeader     string `yaml:"csrf_header"`
+}
+
+// CORSConfig lets browser frontends on other origins call the API. CORS is
+// off while AllowedOrigins is empty. Origins are matched exactly, "*"
+// allows any origin (not with credentials) and "https://*.example.com"
+// allows any subdomain.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// SecurityConfig sets the security headers sent with every response.
+type SecurityConfig struct {
+	// HSTSMaxAge is sent on HTTPS requests only; zero disables HSTS.
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains"`
+	FrameOptions          string        `yaml:"frame_options"`
+	ReferrerPolicy        string        `yaml:"referrer_policy"`
+}
+
+// DeprecationConfig dates the endpoints marked deprecated, such as password
+// signup and login. Dates are YYYY-MM-DD; an empty Sunset omits the Sunset
+// header.
+type DeprecationConfig struct {
+	Since  string `yaml:"since"`
+	Sunset string `yaml:"sunset"`
+}
+
+// DocsConfig loads the Redoc script for the /docs page. RedocIntegrity is
+// its Subresource Integrity hash, "sha384-" followed by the output of
+// "curl -s <url> | openssl dgst -sha384 -binary | openssl base64 -A"; the
+// page is not served without one, so browsers never run an unverified
+// script.
+type DocsConfig struct {
+	RedocURL       string `yaml:"redoc_url"`
+	RedocIntegrity string `yaml:"redoc_integrity"`
+}
+
+// APIKeysConfig limits the personal API keys users create for scripts.
+type APIKeysConfig struct {
+	// DefaultTTL applies when a key is created without an expiry.
+	DefaultTTL time.Duration `yaml:"default_ttl"`
+	MaxTTL     time.Duration `yaml:"max_ttl"`
+	MaxPerUser int           `yaml:"max_per_user"`
+}
+
+// OIDCConfig configures single sign-on through OpenID Connect providers.
+// Providers are listed in the config file; each client secret can also be
+// supplied as OIDC_<NAME>_CLIENT_SECRET (or _FILE), with the provider name
+// upper-cased and dashes replaced by underscores.
+type OIDCConfig struct {
+	// RedirectBaseURL is the public base URL of this API. Providers must
+	// allow <base>/api/auth/oidc/<name>/callback as a redirect URI.
+	RedirectBaseURL string `yaml:"redirect_base_url"`
+	// FrontendURL, when set, receives the browser after login with the token
+	// in the URL fragment; otherwise the callback responds with JSON.
+	FrontendURL string               `yaml:"frontend_url"`
+	StateTTL    time.Duration        `yaml:"state_ttl"`
+	Providers   []OIDCProviderConfig `yaml:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+	// InstitutionID assigns users provisioned through a campus SSO to that
+	// institution. Existing accounts are only linked by email when they
+	// belong to the same institution, or to none when this is empty.
+	InstitutionID string `yaml:"institution_id"`
+}
+
+// SecretEnv is the environment variable that overrides the client secret.
+func (p OIDCProviderConfig) SecretEnv() string {
+	return "OIDC_" + strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")) + "_CLIENT_SECRET"
+}
+
+type InstitutionConfig struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// Domains are matched exactly or as a parent domain, so "uni.edu" also
+	// admits "cs.uni.edu".
+	Domains []string `yaml:"domains"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Mongo: MongoConfig{
+			AutoMigrate: true,
+		},
+		JWT: JWTConfig{
+			TTL:                24 * time.Hour,
+			Algorithm:          "RS256",
+			Issuer:             "student-assistant-app",
+			Audience:           "student-assistant-app",
+			RotationInterval:   30 * 24 * time.Hour,
+			KeyRefreshInterval: 5 * time.Minute,
+			Leeway:             30 * time.Second,
+		},
+		OTP: OTPConfig{
+			TTL:             2 * time.Minute,
+			ResendInterval:  time.Minute,
+			CleanupInterval: 10 * time.Minute,
+			InviteTTL:       72 * time.Hour,
+			MaxAttempts:     5,
+		},
+		Email: EmailConfig{
+			OutboxSize: 100,
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+			CacheTTL:     10 * time.Second,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "student-assistant-app",
+			SampleRatio: 1,
+		},
+		Tenancy: TenancyConfig{
+			Header: "X-Tenant-ID",
+		},
+		Signup: SignupConfig{
+			DefaultRole: enums.User,
+		},
+		Bootstrap: BootstrapConfig{
+			AdminName: "Administrator",
+		},
+		OIDC: OIDCConfig{
+			StateTTL: 10 * time.Minute,
+		},
+		Invitations: InvitationsConfig{
+			TTL:       7 * 24 * time.Hour,
+			AcceptURL: "http://localhost:3000/accept-invitation",
+		},
+		Session: SessionConfig{
+			Mode:           "bearer",
+			CookieName:     "session",
+			CookieSecure:   true,
+			SameSite:       "lax",
+			CSRFCookieName: "csrf_token",
+			CSRFHeader:     "X-CSRF-Token",
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-Request-ID", "X-CSRF-Token", "X-Tenant-ID"},
+			ExposedHeaders: []string{"X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			HSTSIncludeSubdomains: true,
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+		},
+		Deprecation: DeprecationConfig{
+			Since:  "2026-10-19",
+			Sunset: "2027-04-30",
+		},
+		Docs: DocsConfig{
+			RedocURL: "https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js",
+		},
+		APIKeys: APIKeysConfig{
+			DefaultTTL: 90 * 24 * time.Hour,
+			MaxTTL:     365 * 24 * time.Hour,
+			MaxPerUser: 20,
+		},
+	}
+}
+
+// binding ties one setting to its environment variable and command-line flag.
+type binding struct {
+	env   string
+	flag  string
+	usage string
+	set   func(value string) error
+}
+
+func (c *Config) bindings() []binding {
+	return []binding{
+		{"PORT", "port", "HTTP listen port", setString(&c.Server.Port)},
+		{"SERVER_READ_TIMEOUT", "read-timeout", "maximum duration for reading a request", setDuration(&c.Server.ReadTimeout)},
+		{"SERVER_READ_HEADER_TIMEOUT", "read-header-timeout", "maximum duration for reading request headers", setDuration(&c.Server.ReadHeaderTimeout)},
+		{"SERVER_WRITE_TIMEOUT", "write-timeout", "maximum duration for writing a response", setDuration(&c.Server.WriteTimeout)},
+		{"SERVER_IDLE_TIMEOUT", "idle-timeout", "maximum keep-alive idle time", setDuration(&c.Server.IdleTimeout)},
+		{"SERVER_DRAIN_DELAY", "drain-delay", "time to keep serving after readiness is withdrawn", setDuration(&c.Server.DrainDelay)},
+		{"SERVER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "deadline for in-flight requests and workers on shutdown", setDuration(&c.Server.ShutdownTimeout)},
+		{"MONGO_URI", "mongo-uri", "MongoDB connection string", setString(&c.Mongo.URI)},
+		{"DB_NAME", "db-name", "MongoDB database name", setString(&c.Mongo.Database)},
+		{"MONGO_AUTO_MIGRATE", "auto-migrate", "apply pending migrations on server start", setBool(&c.Mongo.AutoMigrate)},
+		{"JWT_SECRET", "jwt-secret", "secret used to verify legacy HS256 JWTs and sign cookies", setString(&c.JWT.Secret)},
+		{"JWT_TTL", "jwt-ttl", "lifetime of issued JWTs", setDuration(&c.JWT.TTL)},
+		{"JWT_ALGORITHM", "jwt-algorithm", "algorithm for new JWT signing keys (RS256, ES256, EdDSA)", setString(&c.JWT.Algorithm)},
+		{"JWT_ISSUER", "jwt-issuer", "iss claim issued and required in JWTs", setString(&c.JWT.Issuer)},
+		{"JWT_AUDIENCE", "jwt-audience", "aud claim issued and required in JWTs", setString(&c.JWT.Audience)},
+		{"JWT_ROTATION_INTERVAL", "jwt-rotation-interval", "how long a JWT signing key is used before rotation", setDuration(&c.JWT.RotationInterval)},
+		{"JWT_KEY_REFRESH_INTERVAL", "jwt-key-refresh-interval", "how often JWT signing keys are reloaded and rotated", setDuration(&c.JWT.KeyRefreshInterval)},
+		{"JWT_KEY_ENCRYPTION_KEY", "jwt-key-encryption-key", "secret used to encrypt JWT signing keys at rest", setString(&c.JWT.KeyEncryptionKey)},
+		{"JWT_ACCEPT_HS256", "jwt-accept-hs256", "accept legacy HS256 JWTs signed with JWT_SECRET", setBool(&c.JWT.AcceptHS256)},
+		{"JWT_LEEWAY", "jwt-leeway", "clock skew tolerated when validating JWT times", setDuration(&c.JWT.Leeway)},
+		{"OTP_SECRET", "otp-secret", "secret used to hash OTP codes", setString(&c.OTP.Secret)},
+		{"OTP_TTL", "otp-ttl", "lifetime of issued OTP codes", setDuration(&c.OTP.TTL)},
+		{"OTP_RESEND_INTERVAL", "otp-resend-interval", "minimum wait before an OTP can be resent", setDuration(&c.OTP.ResendInterval)},
+		{"OTP_CLEANUP_INTERVAL", "otp-cleanup-interval", "how often expired OTPs are purged", setDuration(&c.OTP.CleanupInterval)},
+		{"OTP_INVITE_TTL", "otp-invite-ttl", "how long set-password codes for invited users stay valid", setDuration(&c.OTP.InviteTTL)},
+		{"OTP_MAX_ATTEMPTS", "otp-max-attempts", "number of tries allowed per OTP code", setInt(&c.OTP.MaxAttempts)},
+		{"EMAIL_HOST", "email-host", "SMTP host", setString(&c.Email.Host)},
+		{"EMAIL_PORT", "email-port", "SMTP port", setInt(&c.Email.Port)},
+		{"EMAIL_USERNAME", "email-username", "SMTP username", setString(&c.Email.Username)},
+		{"EMAIL_PASSWORD", "email-password", "SMTP password", setString(&c.Email.Password)},
+		{"EMAIL_FROM", "email-from", "sender address for outgoing email", setString(&c.Email.From)},
+		{"EMAIL_OUTBOX_SIZE", "email-outbox-size", "number of emails queued for background delivery", setInt(&c.Email.OutboxSize)},
+		{"HEALTH_CHECK_TIMEOUT", "health-check-timeout", "timeout for each dependency health check", setDuration(&c.Health.CheckTimeout)},
+		{"HEALTH_CACHE_TTL", "health-cache-ttl", "how long dependency health results are reused", setDuration(&c.Health.CacheTTL)},
+		{"LOG_LEVEL", "log-level", "minimum log level (debug, info, warn, error)", setString(&c.Log.Level)},
+		{"LOG_FORMAT", "log-format", "log output format (json, text)", setString(&c.Log.Format)},
+		{"TRACING_EXPORTER", "tracing-exporter", "trace exporter (none, stdout, otlp)", setString(&c.Tracing.Exporter)},
+		{"TRACING_ENDPOINT", "tracing-endpoint", "OTLP/HTTP collector URL", setString(&c.Tracing.Endpoint)},
+		{"TRACING_SERVICE_NAME", "tracing-service-name", "service name reported in traces", setString(&c.Tracing.ServiceName)},
+		{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of traces to sample (0-1)", setFloat(&c.Tracing.SampleRatio)},
+		{"SIGNUP_INSTITUTIONS", "signup-institutions", "allowed institutions as id=domain,domain;id=domain", setInstitutions(&c.Signup.Institutions)},
+		{"SIGNUP_BLOCK_DISPOSABLE", "signup-block-disposable", "reject disposable email domains at signup", setBool(&c.Signup.BlockDisposable)},
+		{"SIGNUP_DISPOSABLE_DOMAINS", "signup-disposable-domains", "extra disposable email domains, comma separated", setList(&c.Signup.DisposableDomains)},
+		{"SIGNUP_DEFAULT_ROLE", "signup-default-role", "role assigned to new accounts (USER or GUEST)", func(value string) error {
+			c.Signup.DefaultRole = enums.Role(strings.ToUpper(value))
+			return nil
+		}},
+		{"BOOTSTRAP_ADMIN_EMAIL", "bootstrap-admin-email", "email of the first admin, used only while no admin exists", setString(&c.Bootstrap.AdminEmail)},
+		{"BOOTSTRAP_ADMIN_PASSWORD", "bootstrap-admin-password", "password for a newly created bootstrap admin", setString(&c.Bootstrap.AdminPassword)},
+		{"BOOTSTRAP_ADMIN_NAME", "bootstrap-admin-name", "name for a newly created bootstrap admin", setString(&c.Bootstrap.AdminName)},
+		{"OIDC_REDIRECT_BASE_URL", "oidc-redirect-base-url", "public base URL used to build OIDC callback URLs", setString(&c.OIDC.RedirectBaseURL)},
+		{"OIDC_FRONTEND_URL", "oidc-frontend-url", "frontend URL that receives the token after SSO login", setString(&c.OIDC.FrontendURL)},
+		{"OIDC_STATE_TTL", "oidc-state-ttl", "how long an SSO login may take", setDuration(&c.OIDC.StateTTL)},
+		{"TENANT_HEADER", "tenant-header", "request header carrying the institution ID", setString(&c.Tenancy.Header)},
+		{"TENANT_BASE_DOMAIN", "tenant-base-domain", "base domain whose subdomains name institutions", setString(&c.Tenancy.BaseDomain)},
+		{"INVITATION_TTL", "invitation-ttl", "how long invitations stay valid", setDuration(&c.Invitations.TTL)},
+		{"INVITATION_ACCEPT_URL", "invitation-accept-url", "frontend URL that accepts invitations", setString(&c.Invitations.AcceptURL)},
+		{"SESSION_MODE", "session-mode", "how browsers hold their token (bearer, cookie)", setString(&c.Session.Mode)},
+		{"SESSION_COOKIE_NAME", "session-cookie-name", "name of the session cookie", setString(&c.Session.CookieName)},
+		{"SESSION_COOKIE_DOMAIN", "session-cookie-domain", "domain of the session and CSRF cookies", setString(&c.Session.CookieDomain)},
+		{"SESSION_COOKIE_SECURE", "session-cookie-secure", "send session cookies over HTTPS only", setBool(&c.Session.CookieSecure)},
+		{"SESSION_SAME_SITE", "session-same-site", "SameSite policy of session cookies (lax, strict, none)", setString(&c.Session.SameSite)},
+		{"CSRF_COOKIE_NAME", "csrf-cookie-name", "name of the CSRF cookie", setString(&c.Session.CSRFCookieName)},
+		{"CSRF_HEADER", "csrf-header", "request header that must echo the CSRF cookie", setString(&c.Session.CSRFHeader)},
+		{"CORS_ALLOWED_ORIGINS", "cors-allowed-origins", "origins allowed to call the API, comma separated", setList(&c.CORS.AllowedOrigins)},
+		{"CORS_ALLOW_CREDENTIALS", "cors-allow-credentials", "allow cookies and credentials on cross-origin requests", setBool(&c.CORS.AllowCredentials)},
+		{"CORS_ALLOWED_METHODS", "cors-allowed-methods", "methods allowed on cross-origin requests, comma separated", setList(&c.CORS.AllowedMethods)},
+		{"CORS_ALLOWED_HEADERS", "cors-allowed-headers", "request headers allowed on cross-origin requests, comma separated", setList(&c.CORS.AllowedHeaders)},
+		{"CORS_EXPOSED_HEADERS", "cors-exposed-headers", "response headers exposed to cross-origin callers, comma separated", setList(&c.CORS.ExposedHeaders)},
+		{"CORS_MAX_AGE", "cors-max-age", "how long browsers may cache preflight results", setDuration(&c.CORS.MaxAge)},
+		{"SECURITY_HSTS_MAX_AGE", "security-hsts-max-age", "Strict-Transport-Security max-age (0 disables)", setDuration(&c.Security.HSTSMaxAge)},
+		{"SECURITY_HSTS_INCLUDE_SUBDOMAINS", "security-hsts-include-subdomains", "add includeSubDomains to Strict-Transport-Security", setBool(&c.Security.HSTSIncludeSubdomains)},
+		{"SECURITY_FRAME_OPTIONS", "security-frame-options", "X-Frame-Options value (DENY, SAMEORIGIN)", setString(&c.Security.FrameOptions)},
+		{"SECURITY_REFERRER_POLICY", "security-referrer-policy", "Referrer-Policy value", setString(&c.Security.ReferrerPolicy)},
+		{"DEPRECATION_SINCE", "deprecation-since", "date the deprecated endpoints were deprecated (YYYY-MM-DD)", setString(&c.Deprecation.Since)},
+		{"DEPRECATION_SUNSET", "deprecation-sunset", "date the deprecated endpoints will be removed (YYYY-MM-DD, empty for none)", setString(&c.Deprecation.Sunset)},
+		{"DOCS_REDOC_URL", "docs-redoc-url", "URL of the Redoc script used by /docs", setString(&c.Docs.RedocURL)},
+		{"DOCS_REDOC_INTEGRITY", "docs-redoc-integrity", "Subresource Integrity hash of the Redoc script; /docs is disabled without it", setString(&c.Docs.RedocIntegrity)},
+		{"API_KEY_DEFAULT_TTL", "api-key-default-ttl", "lifetime of API keys created without an expiry", setDuration(&c.APIKeys.DefaultTTL)},
+		{"API_KEY_MAX_TTL", "api-key-max-ttl", "longest lifetime an API key may have", setDuration(&c.APIKeys.MaxTTL)},
+		{"API_KEY_MAX_PER_USER", "api-key-max-per-user", "number of API keys each user may hold", setInt(&c.APIKeys.MaxPerUser)},
+	}
+}
+
+// Load builds the configuration from args (without the program name), the
+// process environment and the optional config file, then validates it. The
+// positional arguments left after flag parsing are returned alongside.
+func Load(args []string) (*Config, []string, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("config: loading .env: %w", err)
+	}
+
+	cfg := Default()
+	bindings := cfg.bindings()
+
+	flagSet := flag.NewFlagSet("student-assistant-app", flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flagValues := make(map[string]string)
+	for _, b := range bindings {
+		name := b.flag
+		flagSet.Func(name, b.usage+" (env "+b.env+")", func(value string) error {
+			flagValues[name] = value
+			return nil
+		})
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, b := range bindings {
+		value, ok, err := lookupEnv(b.env)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+		if err := b.set(value); err != nil {
+			return nil, nil, fmt.Errorf("config: invalid %s: %w", b.env, err)
+		}
+	}
+
+	for i := range cfg.OIDC.Providers {
+		provider := &cfg.OIDC.Providers[i]
+		value, ok, err := lookupEnv(provider.SecretEnv())
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			provider.ClientSecret = value
+		}
+	}
+
+	for _, b := range bindings {
+		value, ok := flagValues[b.flag]
+		if !ok {
+			continue
+		}
+		if err := b.set(value); err != nil {
+			return nil, nil, fmt.Errorf("config: invalid -%s: %w", b.flag, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, flagSet.Args(), nil
+}
+
+// Validate reports every missing or invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	required := func(value, name, env string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required (set %s)", name, env))
+		}
+	}
+	positive := func(value time.Duration, name, env string) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be a positive duration (set %s)", name, env))
+		}
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port %q is not a valid port (set PORT)", c.Server.Port))
+	}
+	positive(c.Server.ReadTimeout, "server.read_timeout", "SERVER_READ_TIMEOUT")
+	positive(c.Server.ReadHeaderTimeout, "server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT")
+	positive(c.Server.WriteTimeout, "server.write_timeout", "SERVER_WRITE_TIMEOUT")
+	positive(c.Server.IdleTimeout, "server.idle_timeout", "SERVER_IDLE_TIMEOUT")
+	positive(c.Server.ShutdownTimeout, "server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT")
+	if c.Server.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("server.drain_delay must not be negative (set SERVER_DRAIN_DELAY)"))
+	}
+	required(c.Mongo.URI, "mongo.uri", "MONGO_URI")
+	required(c.Mongo.Database, "mongo.database", "DB_NAME")
+	required(c.JWT.Secret, "jwt.secret", "JWT_SECRET")
+	required(c.JWT.KeyEncryptionKey, "jwt.key_encryption_key", "JWT_KEY_ENCRYPTION_KEY")
+	positive(c.JWT.TTL, "jwt.ttl", "JWT_TTL")
+	switch c.JWT.Algorithm {
+	case "RS256", "ES256", "EdDSA":
+	default:
+		errs = append(errs, fmt.Errorf("jwt.algorithm %q must be RS256, ES256 or EdDSA (set JWT_ALGORITHM)", c.JWT.Algorithm))
+	}
+	if c.JWT.Leeway < 0 || c.JWT.Leeway > 5*time.Minute {
+		errs = append(errs, fmt.Errorf("jwt.leeway must be between 0 and 5m (set JWT_LEEWAY)"))
+	}
+	required(c.JWT.Issuer, "jwt.issuer", "JWT_ISSUER")
+	required(c.JWT.Audience, "jwt.audience", "JWT_AUDIENCE")
+	positive(c.JWT.RotationInterval, "jwt.rotation_interval", "JWT_ROTATION_INTERVAL")
+	positive(c.JWT.KeyRefreshInterval, "jwt.key_refresh_interval", "JWT_KEY_REFRESH_INTERVAL")
+	required(c.OTP.Secret, "otp.secret", "OTP_SECRET")
+	positive(c.OTP.TTL, "otp.ttl", "OTP_TTL")
+	positive(c.OTP.ResendInterval, "otp.resend_interval", "OTP_RESEND_INTERVAL")
+	positive(c.OTP.CleanupInterval, "otp.cleanup_interval", "OTP_CLEANUP_INTERVAL")
+	positive(c.OTP.InviteTTL, "otp.invite_ttl", "OTP_INVITE_TTL")
+	if c.OTP.MaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("otp.max_attempts must be positive (set OTP_MAX_ATTEMPTS)"))
+	}
+	required(c.Email.Host, "email.host", "EMAIL_HOST")
+	if c.Email.Port <= 0 || c.Email.Port > 65535 {
+		errs = append(errs, fmt.Errorf("email.port %d is not a valid port (set EMAIL_PORT)", c.Email.Port))
+	}
+	required(c.Email.Username, "email.username", "EMAIL_USERNAME")
+	required(c.Email.Password, "email.password", "EMAIL_PASSWORD")
+	required(c.Email.From, "email.from", "EMAIL_FROM")
+	if c.Email.OutboxSize < 0 {
+		errs = append(errs, fmt.Errorf("email.outbox_size must not be negative (set EMAIL_OUTBOX_SIZE)"))
+	}
+	positive(c.Health.CheckTimeout, "health.check_timeout", "HEALTH_CHECK_TIMEOUT")
+	if c.Health.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("health.cache_ttl must not be negative (set HEALTH_CACHE_TTL)"))
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level %q must be debug, info, warn or error (set LOG_LEVEL)", c.Log.Level))
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("log.format %q must be json or text (set LOG_FORMAT)", c.Log.Format))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter %q must be none, stdout or otlp (set TRACING_EXPORTER)", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1 (set TRACING_SAMPLE_RATIO)"))
+	}
+	required(c.Tracing.ServiceName, "tracing.service_name", "TRACING_SERVICE_NAME")
+	if c.Signup.DefaultRole != enums.User && c.Signup.DefaultRole != enums.Guest {
+		errs = append(errs, fmt.Errorf("signup.default_role %q must be USER or GUEST (set SIGNUP_DEFAULT_ROLE)", c.Signup.DefaultRole))
+	}
+	if c.Bootstrap.AdminEmail != "" && c.Bootstrap.AdminPassword == "" {
+		errs = append(errs, fmt.Errorf("bootstrap.admin_password is required with bootstrap.admin_email (set BOOTSTRAP_ADMIN_PASSWORD)"))
+	}
+	positive(c.Invitations.TTL, "invitations.ttl", "INVITATION_TTL")
+	if u, err := url.Parse(c.Invitations.AcceptURL); err != nil || !u.IsAbs() {
+		errs = append(errs, fmt.Errorf("invitations.accept_url %q must be an absolute URL (set INVITATION_ACCEPT_URL)", c.Invitations.AcceptURL))
+	}
+	switch c.Session.Mode {
+	case "bearer", "cookie":
+	default:
+		errs = append(errs, fmt.Errorf("session.mode %q must be bearer or cookie (set SESSION_MODE)", c.Session.Mode))
+	}
+	switch c.Session.SameSite {
+	case "lax", "strict":
+	case "none":
+		if !c.Session.CookieSecure {
+			errs = append(errs, fmt.Errorf("session.same_site none requires session.cookie_secure (set SESSION_COOKIE_SECURE)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("session.same_site %q must be lax, strict or none (set SESSION_SAME_SITE)", c.Session.SameSite))
+	}
+	if c.Session.Mode == "cookie" {
+		required(c.Session.CookieName, "session.cookie_name", "SESSION_COOKIE_NAME")
+		required(c.Session.CSRFCookieName, "session.csrf_cookie_name", "CSRF_COOKIE_NAME")
+		required(c.Session.CSRFHeader, "session.csrf_header", "CSRF_HEADER")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				errs = append(errs, fmt.Errorf("cors.allowed_origins cannot be * with cors.allow_credentials (set CORS_ALLOWED_ORIGINS)"))
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins entry %q must be scheme://host[:port] (set CORS_ALLOWED_ORIGINS)", origin))
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("cors.max_age must not be negative (set CORS_MAX_AGE)"))
+	}
+	if c.Security.HSTSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("security.hsts_max_age must not be negative (set SECURITY_HSTS_MAX_AGE)"))
+	}
+	switch c.Security.FrameOptions {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		errs = append(errs, fmt.Errorf("security.frame_options %q must be DENY, SAMEORIGIN or empty (set SECURITY_FRAME_OPTIONS)", c.Security.FrameOptions))
+	}
+	if c.Docs.RedocIntegrity != "" && !strings.HasPrefix(c.Docs.RedocIntegrity, "sha256-") && !strings.HasPrefix(c.Docs.RedocIntegrity, "sha384-") && !strings.HasPrefix(c.Docs.RedocIntegrity, "sha512-") {
+		errs = append(errs, fmt.Errorf("docs.redoc_integrity must start with sha256-, sha384- or sha512- (set DOCS_REDOC_INTEGRITY)"))
+	}
+	since, sinceErr := time.Parse(time.DateOnly, c.Deprecation.Since)
+	if sinceErr != nil {
+		errs = append(errs, fmt.Errorf("deprecation.since %q must be a YYYY-MM-DD date (set DEPRECATION_SINCE)", c.Deprecation.Since))
+	}
+	if c.Deprecation.Sunset != "" {
+		sunset, err := time.Parse(time.DateOnly, c.Deprecation.Sunset)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("deprecation.sunset %q must be a YYYY-MM-DD date (set DEPRECATION_SUNSET)", c.Deprecation.Sunset))
+		case sinceErr == nil && !sunset.After(since):
+			errs = append(errs, fmt.Errorf("deprecation.sunset must be after deprecation.since (set DEPRECATION_SUNSET)"))
+		}
+	}
+	positive(c.APIKeys.DefaultTTL, "api_keys.default_ttl", "API_KEY_DEFAULT_TTL")
+	positive(c.APIKeys.MaxTTL, "api_keys.max_ttl", "API_KEY_MAX_TTL")
+	if c.APIKeys.DefaultTTL > c.APIKeys.MaxTTL {
+		errs = append(errs, fmt.Errorf("api_keys.default_ttl must not exceed api_keys.max_ttl (set API_KEY_DEFAULT_TTL)"))
+	}
+	if c.APIKeys.MaxPerUser <= 0 {
+		errs = append(errs, fmt.Errorf("api_keys.max_per_user must be positive (set API_KEY_MAX_PER_USER)"))
+	}
+	if len(c.OIDC.Providers) > 0 {
+		if u, err := url.Parse(c.OIDC.RedirectBaseURL); err != nil || !u.IsAbs() {
+			errs = append(errs, fmt.Errorf("oidc.redirect_base_url %q must be an absolute URL (set OIDC_REDIRECT_BASE_URL)", c.OIDC.RedirectBaseURL))
+		}
+		positive(c.OIDC.StateTTL, "oidc.state_ttl", "OIDC_STATE_TTL")
+	}
+	seenProviders := make(map[string]bool)
+	for i, provider := range c.OIDC.Providers {
+		switch {
+		case provider.Name == "":
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].name is required", i))
+		case seenProviders[provider.Name]:
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].name %q is duplicated", i, provider.Name))
+		}
+		seenProviders[provider.Name] = true
+		if u, err := url.Parse(provider.Issuer); err != nil || !u.IsAbs() {
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].issuer %q must be an absolute URL", i, provider.Issuer))
+		}
+		if provider.ClientID == "" {
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].client_id is required", i))
+		}
+	}
+	seenInstitutions := make(map[string]bool)
+	for i, institution := range c.Signup.Institutions {
+		switch {
+		case institution.ID == "":
+			errs = append(errs, fmt.Errorf("signup.institutions[%d].id is required", i))
+		case seenInstitutions[institution.ID]:
+			errs = append(errs, fmt.Errorf("signup.institutions[%d].id %q is duplicated", i, institution.ID))
+		}
+		seenInstitutions[institution.ID] = true
+		if len(institution.Domains) == 0 {
+			errs = append(errs, fmt.Errorf("signup.institutions[%d].domains must not be empty", i))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	case ".toml":
+		// TOML is normalised through the YAML decoder so both formats share
+		// the same field names and duration syntax.
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(raw); err != nil {
+			return fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config: unsupported config file type %q", filepath.Ext(path))
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// lookupEnv reads name from the environment, falling back to the contents of
+// the file named by name_FILE so secrets can be mounted rather than exported.
+func lookupEnv(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	path, fileOK := os.LookupEnv(name + "_FILE")
+	if ok && fileOK {
+		return "", false, fmt.Errorf("config: set only one of %s and %s_FILE", name, name)
+	}
+	if !fileOK {
+		return value, ok, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("config: reading %s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func setString(target *string) func(string) error {
+	return func(value string) error {
+		*target = value
+		return nil
+	}
+}
+
+func setInt(target *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target = n
+		return nil
+	}
+}
+
+func setList(target *[]string) func(string) error {
+	return func(value string) error {
+		*target = splitList(value, ",")
+		return nil
+	}
+}
+
+// setInstitutions parses "id=domain,domain;id=domain". Institution names can
+// only be set from a config file.
+func setInstitutions(target *[]InstitutionConfig) func(string) error {
+	return func(value string) error {
+		var institutions []InstitutionConfig
+		for _, entry := range splitList(value, ";") {
+			id, domains, ok := strings.Cut(entry, "=")
+			if !ok {
+				return fmt.Errorf("institution %q is not in id=domain,domain form", entry)
+			}
+			institutions = append(institutions, InstitutionConfig{
+				ID:      strings.TrimSpace(id),
+				Name:    strings.TrimSpace(id),
+				Domains: splitList(domains, ","),
+			})
+		}
+		*target = institutions
+		return nil
+	}
+}
+
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func setBool(target *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*target = b
+		return nil
+	}
+}
+
+func setFloat(target *float64) func(string) error {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*target = f
+		return nil
+	}
+}
+
+func setDuration(target *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*target = d
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// Create API key for the current user
+func (ac *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var createAPIKeyRequest request.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&createAPIKeyRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Bad request"})
+		return
+	}
+
+	createAPIKeyResponse, err := ac.apiKeyService.CreateAPIKey(ctx.Request.Context(), ctx.GetString("userID"), &createAPIKeyRequest)
+	if err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createAPIKeyResponse)
+}
+
+// Get API keys of the current user
+func (ac *APIKeyController) GetAPIKeys(ctx *gin.Context) {
+	apiKeys, err := ac.apiKeyService.GetAPIKeys(ctx.Request.Context(), ctx.GetString("userID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve API keys"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "API keys retrieved successfully",
+		"api_keys": apiKeys,
+	})
+}
+
+// Revoke API key of the current user
+func (ac *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	if err := ac.apiKeyService.RevokeAPIKey(ctx.Request.Context(), ctx.GetString("userID"), ctx.Param("id")); err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+// apiKeyErrorStatus maps API key errors to HTTP status codes
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrAPIKeyScopeDenied):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTooManyAPIKeys):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/openapi"
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// docsPage renders the OpenAPI document with Redoc.
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Student Assistant App API</title>
+<meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+<redoc spec-url="/openapi.json"></redoc>
+<script src="{{.RedocURL}}" integrity="{{.RedocIntegrity}}" crossorigin="anonymous"></script>
+</body>
+</html>
+`))
+
+type DocsController struct {
+	spec []byte
+	page []byte
+}
+
+// NewDocsController renders the document and page once; they do not change
+// while the server runs. Without an integrity hash for the Redoc script the
+// page is not rendered.
+func NewDocsController(document *openapi.Document, docsConfig config.DocsConfig) (*DocsController, error) {
+	spec, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var page bytes.Buffer
+	if docsConfig.RedocIntegrity != "" {
+		if err := docsPage.Execute(&page, docsConfig); err != nil {
+			return nil, err
+		}
+	}
+	return &DocsController{
+		spec: spec,
+		page: page.Bytes(),
+	}, nil
+}
+
+// OpenAPI document endpoint
+func (dc *DocsController) OpenAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", dc.spec)
+}
+
+// API reference page endpoint
+func (dc *DocsController) Docs(ctx *gin.Context) {
+	if len(dc.page) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "API reference page is disabled; set DOCS_REDOC_INTEGRITY"})
+		return
+	}
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", dc.page)
+}
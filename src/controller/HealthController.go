@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/buildinfo"
+	"Student-Assistant-App/src/health"
+	"Student-Assistant-App/src/server"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	registry  *health.Registry
+	readiness *server.Readiness
+}
+
+func NewHealthController(registry *health.Registry, readiness *server.Readiness) *HealthController {
+	return &HealthController{
+		registry:  registry,
+		readiness: readiness,
+	}
+}
+
+// Liveness probe: the process is up and serving requests
+func (hc *HealthController) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// Readiness probe: the server is accepting traffic and its dependencies are healthy
+func (hc *HealthController) Readyz(ctx *gin.Context) {
+	if !hc.readiness.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusDown, "message": "Server is shutting down"})
+		return
+	}
+
+	report := hc.registry.Run(ctx.Request.Context())
+	statusCode := http.StatusOK
+	if !report.Healthy() {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	ctx.JSON(statusCode, gin.H{"status": report.Status})
+}
+
+// Detailed health for administrators, including dependency latency and build info
+func (hc *HealthController) DetailedHealth(ctx *gin.Context) {
+	report := hc.registry.Run(ctx.Request.Context())
+	if !hc.readiness.Ready() {
+		report.Status = health.StatusDown
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Health retrieved successfully",
+		"status":  report.Status,
+		"ready":   hc.readiness.Ready(),
+		"checks":  report.Checks,
+		"build":   buildinfo.Get(),
+	})
+}
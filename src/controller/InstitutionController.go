@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InstitutionController struct {
+	institutionService service.InstitutionService
+}
+
+func NewInstitutionController(institutionService service.InstitutionService) *InstitutionController {
+	return &InstitutionController{
+		institutionService: institutionService,
+	}
+}
+
+// Create institution
+func (ic *InstitutionController) CreateInstitution(ctx *gin.Context) {
+	var createInstitutionRequest request.CreateInstitutionRequest
+	if err := ctx.ShouldBindJSON(&createInstitutionRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Bad request"})
+		return
+	}
+
+	institution, err := ic.institutionService.CreateInstitution(ctx.Request.Context(), &createInstitutionRequest)
+	if err != nil {
+		ctx.JSON(institutionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":     "Institution created successfully",
+		"institution": institution,
+	})
+}
+
+// Get all institutions
+func (ic *InstitutionController) GetAllInstitutions(ctx *gin.Context) {
+	institutions, err := ic.institutionService.GetAllInstitutions(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve institutions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":      "Institutions retrieved successfully",
+		"institutions": institutions,
+	})
+}
+
+// Get institution by ID
+func (ic *InstitutionController) GetInstitution(ctx *gin.Context) {
+	institution, err := ic.institutionService.GetInstitution(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(institutionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Institution retrieved successfully",
+		"institution": institution,
+	})
+}
+
+// Update institution
+func (ic *InstitutionController) UpdateInstitution(ctx *gin.Context) {
+	var updateInstitutionRequest request.UpdateInstitutionRequest
+	if err := ctx.ShouldBindJSON(&updateInstitutionRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Bad request"})
+		return
+	}
+
+	institution, err := ic.institutionService.UpdateInstitution(ctx.Request.Context(), ctx.Param("id"), &updateInstitutionRequest)
+	if err != nil {
+		ctx.JSON(institutionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Institution updated successfully",
+		"institution": institution,
+	})
+}
+
+// Delete institution
+func (ic *InstitutionController) DeleteInstitution(ctx *gin.Context) {
+	if err := ic.institutionService.DeleteInstitution(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.JSON(institutionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Institution deleted successfully"})
+}
+
+// institutionErrorStatus maps institution service errors to HTTP status codes
+func institutionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInstitutionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrDuplicateInstitution), errors.Is(err, service.ErrInstitutionInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/session"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationController struct {
+	invitationService service.InvitationService
+	sessions          *session.Cookies
+}
+
+func NewInvitationController(invitationService service.InvitationService, sessions *session.Cookies) *InvitationController {
+	return &InvitationController{
+		invitationService: invitationService,
+		sessions:          sessions,
+	}
+}
+
+// Create invitation
+func (ic *InvitationController) CreateInvitation(ctx *gin.Context) {
+	var createInvitationRequest request.CreateInvitationRequest
+	if err := ctx.ShouldBindJSON(&createInvitationRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Bad request"})
+		return
+	}
+
+	invitation, err := ic.invitationService.CreateInvitation(ctx.Request.Context(), currentActor(ctx), &createInvitationRequest)
+	if err != nil {
+		ctx.JSON(invitationErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":    "Invitation sent successfully",
+		"invitation": invitation,
+	})
+}
+
+// Get all invitations
+func (ic *InvitationController) GetAllInvitations(ctx *gin.Context) {
+	invitations, err := ic.invitationService.GetAllInvitations(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve invitations"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Invitations retrieved successfully",
+		"invitations": invitations,
+	})
+}
+
+// Revoke invitation
+func (ic *InvitationController) RevokeInvitation(ctx *gin.Context) {
+	if err := ic.invitationService.RevokeInvitation(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invitation revoked successfully"})
+}
+
+// Accept invitation and create the invited account
+func (ic *InvitationController) AcceptInvitation(ctx *gin.Context) {
+	var acceptInvitationRequest request.AcceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&acceptInvitationRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Bad request"})
+		return
+	}
+
+	createUserResponse, err := ic.invitationService.AcceptInvitation(ctx.Request.Context(), &acceptInvitationRequest)
+	if err != nil {
+		ctx.JSON(invitationErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := startSession(ctx, ic.sessions, &createUserResponse.Token, &createUserResponse.CSRFToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start session"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createUserResponse)
+}
+
+// invitationErrorStatus maps invitation errors to HTTP status codes
+func invitationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidInvitation):
+		return http.StatusGone
+	case errors.Is(err, service.ErrInvitationRoleDenied):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInstitutionNotFound):
+		return http.StatusNotFound
+	default:
+		return signupErrorStatus(err)
+	}
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/keys"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KeyController struct {
+	keyStore *keys.Store
+}
+
+func NewKeyController(keyStore *keys.Store) *KeyController {
+	return &KeyController{
+		keyStore: keyStore,
+	}
+}
+
+// JWKS endpoint: public keys that verify issued tokens
+func (kc *KeyController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, kc.keyStore.JWKS())
+}
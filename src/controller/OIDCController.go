@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/session"
+	"Student-Assistant-App/src/utils"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie carries the signed login state between the redirect to the
+// provider and the callback.
+const oidcStateCookie = "oidc_login"
+
+type OIDCController struct {
+	oidcService service.OIDCService
+	// secret signs the login state cookie.
+	secret      string
+	frontendURL string
+	sessions    *session.Cookies
+}
+
+func NewOIDCController(oidcService service.OIDCService, secret, frontendURL string, sessions *session.Cookies) *OIDCController {
+	return &OIDCController{
+		oidcService: oidcService,
+		secret:      secret,
+		frontendURL: frontendURL,
+		sessions:    sessions,
+	}
+}
+
+// List configured SSO providers
+func (oc *OIDCController) GetProviders(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":   "Providers retrieved successfully",
+		"providers": oc.oidcService.Providers(),
+	})
+}
+
+// Start SSO login by redirecting to the provider
+func (oc *OIDCController) StartLogin(ctx *gin.Context) {
+	authURL, loginState, err := oc.oidcService.AuthCodeURL(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownProvider) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadGateway, gin.H{"message": "SSO provider is unavailable"})
+		return
+	}
+
+	payload, err := json.Marshal(loginState)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start SSO login"})
+		return
+	}
+	value := utils.SignValue(base64.RawURLEncoding.EncodeToString(payload), oc.secret)
+	oc.setStateCookie(ctx, value, int(time.Until(loginState.ExpiresAt).Seconds()))
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// Complete SSO login from the provider's redirect
+func (oc *OIDCController) Callback(ctx *gin.Context) {
+	loginState := oc.readStateCookie(ctx)
+	oc.setStateCookie(ctx, "", -1)
+
+	if providerError := ctx.Query("error"); providerError != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "SSO login was not completed: " + providerError})
+		return
+	}
+	if loginState != nil && loginState.Provider != ctx.Param("provider") {
+		loginState = nil
+	}
+
+	loginResponse, err := oc.oidcService.Login(ctx.Request.Context(), ctx.Query("code"), ctx.Query("state"), loginState)
+	if err != nil {
+		statusCode := http.StatusUnauthorized
+		switch {
+		case errors.Is(err, service.ErrUnknownProvider):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, service.ErrEmailDomainNotAllowed), errors.Is(err, service.ErrDisposableEmail):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrSSOLinkRefused):
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := startSession(ctx, oc.sessions, &loginResponse.Token, &loginResponse.CSRFToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start session"})
+		return
+	}
+
+	if oc.frontendURL != "" {
+		// In cookie session mode the fragment carries only the CSRF token.
+		fragment := url.Values{}
+		if loginResponse.Token != "" {
+			fragment.Set("token", loginResponse.Token)
+		}
+		if loginResponse.CSRFToken != "" {
+			fragment.Set("csrf_token", loginResponse.CSRFToken)
+		}
+		ctx.Redirect(http.StatusFound, oc.frontendURL+"#"+fragment.Encode())
+		return
+	}
+	ctx.JSON(http.StatusOK, loginResponse)
+}
+
+func (oc *OIDCController) readStateCookie(ctx *gin.Context) *service.OIDCLoginState {
+	cookie, err := ctx.Cookie(oidcStateCookie)
+	if err != nil {
+		return nil
+	}
+	encoded, ok := utils.VerifySignedValue(cookie, oc.secret)
+	if !ok {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+	var loginState service.OIDCLoginState
+	if err := json.Unmarshal(payload, &loginState); err != nil {
+		return nil
+	}
+	return &loginState
+}
+
+func (oc *OIDCController) setStateCookie(ctx *gin.Context, value string, maxAge int) {
+	secure := ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https"
+	// Lax so the cookie survives the top-level redirect back from the provider.
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	// Scoped to /api so a login started under /api/v1 reaches the
+	// unversioned callback registered with providers.
+	ctx.SetCookie(oidcStateCookie, value, maxAge, "/api", "", secure, true)
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startSession hands an issued token to the client. In cookie session mode
+// the token moves into an HttpOnly cookie and is replaced in the response
+// body by the CSRF token; otherwise the body is left as is.
+func startSession(ctx *gin.Context, sessions *session.Cookies, token, csrfToken *string) error {
+	if !sessions.Enabled() || *token == "" {
+		return nil
+	}
+	csrf, err := sessions.Start(ctx, *token)
+	if err != nil {
+		return err
+	}
+	*token = ""
+	*csrfToken = csrf
+	return nil
+}
@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"Student-Assistant-App/src/data/enums"
 	"Student-Assistant-App/src/dtos/request"
 	"Student-Assistant-App/src/dtos/response"
 	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/session"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -15,14 +18,18 @@ type UserController struct {
 	authService  service.AuthService
 	otpService   service.OTPService
 	emailService service.EmailService
+	jobs         service.JobQueue
+	sessions     *session.Cookies
 }
 
-func NewUserController(userService service.UserService, authService service.AuthService, otpService service.OTPService, emailService service.EmailService) *UserController {
+func NewUserController(userService service.UserService, authService service.AuthService, otpService service.OTPService, emailService service.EmailService, jobs service.JobQueue, sessions *session.Cookies) *UserController {
 	return &UserController{
 		userService:  userService,
 		authService:  authService,
 		otpService:   otpService,
 		emailService: emailService,
+		jobs:         jobs,
+		sessions:     sessions,
 	}
 }
 
@@ -41,13 +48,18 @@ func (uc *UserController) SendOTP(ctx *gin.Context) {
 		return
 	}
 
-	// For signup, check if user doesn't exist
+	// For signup, check if user doesn't exist and the email may sign up
 	if sendOTPRequest.Purpose == "signup" {
 		existingUser, _ := uc.userService.GetUserByEmail(ctx.Request.Context(), sendOTPRequest.Email)
 		if existingUser != nil {
 			ctx.JSON(http.StatusConflict, gin.H{"message": "User already exists with this email"})
 			return
 		}
+
+		if err := uc.userService.CheckSignupEmail(ctx.Request.Context(), sendOTPRequest.Email); err != nil {
+			ctx.JSON(signupErrorStatus(err), gin.H{"message": err.Error()})
+			return
+		}
 	}
 
 	// For login, check if user exists
@@ -136,24 +148,23 @@ func (uc *UserController) SignupWithOTP(ctx *gin.Context) {
 		Name:     signupRequest.Name,
 		Email:    signupRequest.Email,
 		Password: signupRequest.Password,
-		Role:     signupRequest.Role,
 	}
 
 	createUserResponse, err := uc.userService.CreateUser(ctx.Request.Context(), createUserRequest)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		if strings.Contains(err.Error(), "already exists") {
-			statusCode = http.StatusConflict
-		}
-
-		ctx.JSON(statusCode, response.CreateUserResponse{
+		ctx.JSON(signupErrorStatus(err), response.CreateUserResponse{
 			Message: err.Error(),
 		})
 		return
 	}
 
 	// Send welcome email
-	uc.emailService.SendWelcomeEmail(signupRequest.Email, signupRequest.Name)
+	uc.emailService.SendWelcomeEmail(ctx.Request.Context(), signupRequest.Email, signupRequest.Name)
+
+	if err := startSession(ctx, uc.sessions, &createUserResponse.Token, &createUserResponse.CSRFToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start session"})
+		return
+	}
 
 	ctx.JSON(http.StatusCreated, createUserResponse)
 }
@@ -188,14 +199,20 @@ func (uc *UserController) LoginWithOTP(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.LoginResponse{
+	loginResponse := response.LoginResponse{
 		Message: "Login successful",
 		User:    user,
 		Token:   token,
-	})
+	}
+	if err := startSession(ctx, uc.sessions, &loginResponse.Token, &loginResponse.CSRFToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start session"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loginResponse)
 }
 
-// Traditional Signup (without OTP - for backward compatibility)
+// Traditional Signup (without OTP - deprecated in favour of SignupWithOTP)
 func (uc *UserController) Signup(ctx *gin.Context) {
 	var createUserRequest request.CreateUserRequest
 	err := ctx.ShouldBindJSON(&createUserRequest)
@@ -215,21 +232,21 @@ func (uc *UserController) Signup(ctx *gin.Context) {
 
 	createUserResponse, err := uc.userService.CreateUser(ctx.Request.Context(), &createUserRequest)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		if strings.Contains(err.Error(), "already exists") {
-			statusCode = http.StatusConflict
-		}
-
-		ctx.JSON(statusCode, response.CreateUserResponse{
+		ctx.JSON(signupErrorStatus(err), response.CreateUserResponse{
 			Message: err.Error(),
 		})
 		return
 	}
 
+	if err := startSession(ctx, uc.sessions, &createUserResponse.Token, &createUserResponse.CSRFToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start session"})
+		return
+	}
+
 	ctx.JSON(http.StatusCreated, createUserResponse)
 }
 
-// Traditional Login (without OTP - for backward compatibility)
+// Traditional Login (without OTP - deprecated in favour of LoginWithOTP)
 func (uc *UserController) Login(ctx *gin.Context) {
 	var loginRequest request.LoginRequest
 	err := ctx.ShouldBindJSON(&loginRequest)
@@ -249,9 +266,32 @@ func (uc *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
+	if err := startSession(ctx, uc.sessions, &loginResponse.Token, &loginResponse.CSRFToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start session"})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, loginResponse)
 }
 
+// Logout endpoint: clears the session cookies
+func (uc *UserController) Logout(ctx *gin.Context) {
+	uc.sessions.End(ctx)
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// signupErrorStatus maps user creation errors to HTTP status codes
+func signupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrEmailDomainNotAllowed), errors.Is(err, service.ErrDisposableEmail):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "already exists"):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
 // Get user by ID
 func (uc *UserController) GetUser(ctx *gin.Context) {
 	id := ctx.Param("id")
@@ -261,7 +301,7 @@ func (uc *UserController) GetUser(ctx *gin.Context) {
 	}
 
 	user, err := uc.userService.GetUserByID(ctx.Request.Context(), id)
-	if err != nil {
+	if err != nil || user == nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
 		return
 	}
@@ -286,6 +326,20 @@ func (uc *UserController) GetAllUsers(ctx *gin.Context) {
 	})
 }
 
+// Get accounts whose emails collide once normalized
+func (uc *UserController) GetDuplicateEmails(ctx *gin.Context) {
+	duplicates, err := uc.userService.FindDuplicateEmails(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve duplicate accounts"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":    "Duplicate accounts retrieved successfully",
+		"duplicates": duplicates,
+	})
+}
+
 // Update user
 func (uc *UserController) UpdateUser(ctx *gin.Context) {
 	id := ctx.Param("id")
@@ -294,6 +348,11 @@ func (uc *UserController) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
+	if !canManageUser(ctx, id) {
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "You can only update your own account"})
+		return
+	}
+
 	var updateUserRequest request.UpdateUserRequest
 	err := ctx.ShouldBindJSON(&updateUserRequest)
 	if err != nil {
@@ -304,7 +363,10 @@ func (uc *UserController) UpdateUser(ctx *gin.Context) {
 	user, err := uc.userService.UpdateUser(ctx.Request.Context(), id, &updateUserRequest)
 	if err != nil {
 		statusCode := http.StatusNotFound
-		if strings.Contains(err.Error(), "validation") {
+		switch {
+		case errors.Is(err, service.ErrEmailDomainNotAllowed), errors.Is(err, service.ErrDisposableEmail), errors.Is(err, service.ErrInstitutionChange):
+			statusCode = http.StatusForbidden
+		case strings.Contains(err.Error(), "validation"):
 			statusCode = http.StatusBadRequest
 		}
 		ctx.JSON(statusCode, gin.H{"message": err.Error()})
@@ -325,6 +387,11 @@ func (uc *UserController) DeleteUser(ctx *gin.Context) {
 		return
 	}
 
+	if !canManageUser(ctx, id) {
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "You can only delete your own account"})
+		return
+	}
+
 	err := uc.userService.DeleteUser(ctx.Request.Context(), id)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
@@ -334,6 +401,49 @@ func (uc *UserController) DeleteUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+// Set a user's role (admin only)
+func (uc *UserController) SetUserRole(ctx *gin.Context) {
+	var setRoleRequest request.SetRoleRequest
+	if err := ctx.ShouldBindJSON(&setRoleRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Bad request"})
+		return
+	}
+
+	user, err := uc.userService.SetUserRole(ctx.Request.Context(), currentActor(ctx), ctx.Param("id"), setRoleRequest.GetRole())
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrRoleChangeDenied), errors.Is(err, service.ErrOwnRoleChange):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "User role updated successfully",
+		"user":    user,
+	})
+}
+
+// currentActor returns the authenticated user as set by AuthMiddleware
+func currentActor(ctx *gin.Context) service.Actor {
+	role, _ := ctx.Get("role")
+	actor := service.Actor{UserID: ctx.GetString("userID")}
+	actor.Role, _ = role.(enums.Role)
+	return actor
+}
+
+// canManageUser reports whether the current user may modify the account
+// with the given ID: their own, or any account (within their institution)
+// for admins
+func canManageUser(ctx *gin.Context, id string) bool {
+	actor := currentActor(ctx)
+	return actor.UserID == id || actor.Role.IsAdmin()
+}
+
 // Get current user (from JWT token)
 func (uc *UserController) GetCurrentUser(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
@@ -343,7 +453,7 @@ func (uc *UserController) GetCurrentUser(ctx *gin.Context) {
 	}
 
 	user, err := uc.userService.GetUserByID(ctx.Request.Context(), userID.(string))
-	if err != nil {
+	if err != nil || user == nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
 		return
 	}
@@ -0,0 +1,625 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/clock"
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/session"
+	"Student-Assistant-App/src/testutil"
+	"Student-Assistant-App/src/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// userControllerFixture serves the user routes from real services backed by
+// in-memory repositories. Requests authenticate as the user passed to serve,
+// standing in for AuthMiddleware.
+type userControllerFixture struct {
+	engine   *gin.Engine
+	userRepo repository.UserRepository
+	emails   *testutil.EmailService
+	outbox   *service.EmailOutbox
+	clock    *clock.Fake
+}
+
+func newUserControllerFixture(t *testing.T, configure func(cfg *config.Config)) *userControllerFixture {
+	t.Helper()
+	cfg := config.Default()
+	cfg.JWT.Secret = "test-secret"
+	cfg.OTP.Secret = "otp-secret"
+	if configure != nil {
+		configure(cfg)
+	}
+
+	logger := testutil.DiscardLogger
+	fakeClock := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	userRepo := repository.NewUserRepositoryMemory()
+	emails := testutil.NewEmailService()
+	signupPolicy := service.NewSignupPolicy(&testutil.InstitutionRepository{}, cfg.Signup)
+	userService := service.NewUserServiceImpl(userRepo, &testutil.AuditRepository{}, signupPolicy, enums.User, testutil.TokenManager{}, logger)
+	authService := service.NewAuthService(userService, testutil.TokenManager{}, logger)
+	otpService := service.NewOTPService(repository.NewOTPRepositoryMemory(fakeClock), emails, cfg.OTP, fakeClock, logger)
+	outbox := service.NewEmailOutbox(emails, cfg.Email.OutboxSize, logger)
+	uc := NewUserController(userService, authService, otpService, emails, outbox, session.New(cfg.Session, cfg.JWT))
+
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) {
+		if userID := ctx.GetHeader("X-Test-User"); userID != "" {
+			ctx.Set("userID", userID)
+			ctx.Set("role", enums.Role(ctx.GetHeader("X-Test-Role")))
+		}
+	})
+	engine.POST("/auth/signup", uc.Signup)
+	engine.POST("/auth/login", uc.Login)
+	engine.POST("/auth/logout", uc.Logout)
+	engine.POST("/auth/send-otp", uc.SendOTP)
+	engine.POST("/auth/verify-otp", uc.VerifyOTP)
+	engine.POST("/auth/resend-otp", uc.ResendOTP)
+	engine.POST("/auth/signup-with-otp", uc.SignupWithOTP)
+	engine.POST("/auth/login-with-otp", uc.LoginWithOTP)
+	engine.POST("/auth/set-password", uc.SetPassword)
+	engine.GET("/users/me", uc.GetCurrentUser)
+	engine.GET("/users/:id", uc.GetUser)
+	engine.PUT("/users/:id", uc.UpdateUser)
+	engine.DELETE("/users/:id", uc.DeleteUser)
+	engine.GET("/admin/users", uc.GetAllUsers)
+	engine.GET("/admin/users/duplicates", uc.GetDuplicateEmails)
+	engine.POST("/admin/users/import", uc.ImportUsers)
+	engine.PUT("/admin/users/:id/role", uc.SetUserRole)
+	engine.GET("/admin/users/export", uc.ExportUsers)
+
+	return &userControllerFixture{engine: engine, userRepo: userRepo, emails: emails, outbox: outbox, clock: fakeClock}
+}
+
+// addUser stores a user with the password "password".
+func (f *userControllerFixture) addUser(t *testing.T, name, email string, role enums.Role) *model.User {
+	t.Helper()
+	hashedPassword, err := utils.HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user, err := f.userRepo.Save(context.Background(), &model.User{Name: name, Email: email, Password: hashedPassword, Role: role})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return user
+}
+
+// serve sends req as actor, or anonymously when actor is nil.
+func (f *userControllerFixture) serve(req *http.Request, actor *model.User) *httptest.ResponseRecorder {
+	if actor != nil {
+		req.Header.Set("X-Test-User", actor.ID.Hex())
+		req.Header.Set("X-Test-Role", string(actor.Role))
+	}
+	recorder := httptest.NewRecorder()
+	f.engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// do sends body, marshalled to JSON unless it is already a string.
+func (f *userControllerFixture) do(t *testing.T, method, path string, body any, actor *model.User) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshalling request: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	return f.serve(req, actor)
+}
+
+// runJobs runs every job queued on the outbox and returns once they are
+// done.
+func (f *userControllerFixture) runJobs() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.outbox.Run(ctx)
+}
+
+// sendCode has a code sent to email for purpose and returns it.
+func (f *userControllerFixture) sendCode(t *testing.T, email, purpose string) string {
+	t.Helper()
+	recorder := f.do(t, http.MethodPost, "/auth/send-otp", gin.H{"email": email, "purpose": purpose}, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("send-otp status = %d: %s", recorder.Code, recorder.Body)
+	}
+	return f.emails.LastCode(email, purpose)
+}
+
+// userResponse is the shape of every response carrying a user.
+type userResponse struct {
+	Message   string      `json:"message"`
+	User      *model.User `json:"user"`
+	Token     string      `json:"token"`
+	CSRFToken string      `json:"csrf_token"`
+}
+
+func decode[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+	var body T
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %q: %v", recorder.Body, err)
+	}
+	return body
+}
+
+func assertStatus(t *testing.T, recorder *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if recorder.Code != want {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, want, recorder.Body)
+	}
+}
+
+func TestSendOTP(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *config.Config)
+		body      any
+		emailErr  error
+		want      int
+		wantSent  bool
+	}{
+		{name: "signup for a new email", body: gin.H{"email": "new@example.com", "purpose": "signup"}, want: http.StatusOK, wantSent: true},
+		{name: "signup for a registered email", body: gin.H{"email": "ada@example.com", "purpose": "signup"}, want: http.StatusConflict},
+		{
+			name:      "signup from a disposable address",
+			configure: func(cfg *config.Config) { cfg.Signup.BlockDisposable = true },
+			body:      gin.H{"email": "new@10minutemail.com", "purpose": "signup"},
+			want:      http.StatusForbidden,
+		},
+		{name: "login", body: gin.H{"email": "ada@example.com", "purpose": "login"}, want: http.StatusOK, wantSent: true},
+		{name: "login for an unknown email", body: gin.H{"email": "new@example.com", "purpose": "login"}, want: http.StatusNotFound},
+		{name: "password reset", body: gin.H{"email": "ada@example.com", "purpose": "password_reset"}, want: http.StatusOK, wantSent: true},
+		{name: "invalid purpose", body: gin.H{"email": "ada@example.com", "purpose": "set_password"}, want: http.StatusBadRequest},
+		{name: "missing purpose", body: gin.H{"email": "ada@example.com"}, want: http.StatusBadRequest},
+		{name: "malformed body", body: "{", want: http.StatusBadRequest},
+		{name: "email failure", body: gin.H{"email": "ada@example.com", "purpose": "login"}, emailErr: errors.New("smtp down"), want: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, test.configure)
+			fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+			fixture.emails.Fail(test.emailErr)
+
+			recorder := fixture.do(t, http.MethodPost, "/auth/send-otp", test.body, nil)
+			assertStatus(t, recorder, test.want)
+			if sent := fixture.emails.Sent() > 0; sent != test.wantSent {
+				t.Errorf("code sent = %v, want %v", sent, test.wantSent)
+			}
+		})
+	}
+}
+
+func TestVerifyOTP(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+	code := fixture.sendCode(t, "ada@example.com", "login")
+
+	tests := []struct {
+		name string
+		body any
+		want int
+	}{
+		{"wrong code", gin.H{"email": "ada@example.com", "code": "000000", "purpose": "login"}, http.StatusBadRequest},
+		{"wrong purpose", gin.H{"email": "ada@example.com", "code": code, "purpose": "signup"}, http.StatusBadRequest},
+		{"missing code", gin.H{"email": "ada@example.com", "purpose": "login"}, http.StatusBadRequest},
+		{"valid code", gin.H{"email": "ada@example.com", "code": code, "purpose": "login"}, http.StatusOK},
+		{"code already used", gin.H{"email": "ada@example.com", "code": code, "purpose": "login"}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assertStatus(t, fixture.do(t, http.MethodPost, "/auth/verify-otp", test.body, nil), test.want)
+		})
+	}
+}
+
+func TestResendOTP(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+	first := fixture.sendCode(t, "ada@example.com", "login")
+	body := gin.H{"email": "ada@example.com", "purpose": "login"}
+
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/resend-otp", body, nil), http.StatusBadRequest)
+
+	fixture.clock.Advance(time.Minute)
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/resend-otp", body, nil), http.StatusOK)
+	if fixture.emails.LastCode("ada@example.com", "login") == first {
+		t.Error("resend did not send a new code")
+	}
+
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/resend-otp", "{", nil), http.StatusBadRequest)
+}
+
+func TestSignupWithOTP(t *testing.T) {
+	tests := []struct {
+		name string
+		body func(code string) any
+		want int
+	}{
+		{
+			name: "valid code",
+			body: func(code string) any {
+				return gin.H{"name": "Grace", "email": "grace@example.com", "password": "secret", "otp_code": code}
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "wrong code",
+			body: func(string) any {
+				return gin.H{"name": "Grace", "email": "grace@example.com", "password": "secret", "otp_code": "000000"}
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "missing password",
+			body: func(code string) any {
+				return gin.H{"name": "Grace", "email": "grace@example.com", "otp_code": code}
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "missing code",
+			body: func(string) any {
+				return gin.H{"name": "Grace", "email": "grace@example.com", "password": "secret"}
+			},
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, nil)
+			code := fixture.sendCode(t, "grace@example.com", "signup")
+
+			recorder := fixture.do(t, http.MethodPost, "/auth/signup-with-otp", test.body(code), nil)
+			assertStatus(t, recorder, test.want)
+			if test.want != http.StatusCreated {
+				if exists, _ := fixture.userRepo.ExistsByEmail(context.Background(), "grace@example.com"); exists {
+					t.Error("a failed signup created the user")
+				}
+				return
+			}
+
+			created := decode[userResponse](t, recorder)
+			if created.User == nil || created.User.Email != "grace@example.com" || created.Token != "token-"+created.User.ID.Hex() {
+				t.Errorf("response = %+v", created)
+			}
+			if len(fixture.emails.Welcomed()) != 1 {
+				t.Errorf("welcome emails = %v, want one", fixture.emails.Welcomed())
+			}
+		})
+	}
+}
+
+func TestLoginWithOTP(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	ada := fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+	code := fixture.sendCode(t, "ada@example.com", "login")
+
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/login-with-otp", gin.H{"email": "ada@example.com", "otp_code": "000000"}, nil), http.StatusUnauthorized)
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/login-with-otp", gin.H{"email": "ada@example.com"}, nil), http.StatusBadRequest)
+
+	recorder := fixture.do(t, http.MethodPost, "/auth/login-with-otp", gin.H{"email": "ADA@example.com", "otp_code": code}, nil)
+	assertStatus(t, recorder, http.StatusOK)
+	if login := decode[userResponse](t, recorder); login.Token != "token-"+ada.ID.Hex() {
+		t.Errorf("token = %q, want one for %s", login.Token, ada.ID.Hex())
+	}
+
+	fixture.clock.Advance(time.Minute)
+	code = fixture.sendCode(t, "ada@example.com", "login")
+	fixture.clock.Advance(3 * time.Minute)
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/login-with-otp", gin.H{"email": "ada@example.com", "otp_code": code}, nil), http.StatusUnauthorized)
+}
+
+func TestSignup(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *config.Config)
+		body      any
+		want      int
+	}{
+		{name: "new user", body: gin.H{"name": "Grace", "email": "grace@example.com", "password": "secret"}, want: http.StatusCreated},
+		{name: "email taken in another case", body: gin.H{"name": "Ada", "email": "ADA@example.com", "password": "secret"}, want: http.StatusConflict},
+		{name: "missing name", body: gin.H{"email": "grace@example.com", "password": "secret"}, want: http.StatusBadRequest},
+		{name: "invalid email", body: gin.H{"name": "Grace", "email": "grace", "password": "secret"}, want: http.StatusBadRequest},
+		{
+			name:      "disposable address",
+			configure: func(cfg *config.Config) { cfg.Signup.BlockDisposable = true },
+			body:      gin.H{"name": "Grace", "email": "grace@10minutemail.com", "password": "secret"},
+			want:      http.StatusForbidden,
+		},
+		{name: "malformed body", body: "[]", want: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, test.configure)
+			fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+
+			recorder := fixture.do(t, http.MethodPost, "/auth/signup", test.body, nil)
+			assertStatus(t, recorder, test.want)
+			if test.want == http.StatusCreated {
+				if created := decode[userResponse](t, recorder); created.User.Role != enums.User || created.Token == "" {
+					t.Errorf("response = %+v", created)
+				}
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+
+	tests := []struct {
+		name string
+		body any
+		want int
+	}{
+		{"correct password", gin.H{"email": "ada@example.com", "password": "password"}, http.StatusOK},
+		{"wrong password", gin.H{"email": "ada@example.com", "password": "wrong"}, http.StatusUnauthorized},
+		{"unknown user", gin.H{"email": "nobody@example.com", "password": "password"}, http.StatusUnauthorized},
+		{"malformed body", "{", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := fixture.do(t, http.MethodPost, "/auth/login", test.body, nil)
+			assertStatus(t, recorder, test.want)
+			if login := decode[userResponse](t, recorder); (login.Token != "") != (test.want == http.StatusOK) {
+				t.Errorf("token = %q", login.Token)
+			}
+		})
+	}
+}
+
+func TestCookieSession(t *testing.T) {
+	fixture := newUserControllerFixture(t, func(cfg *config.Config) { cfg.Session.Mode = "cookie" })
+	fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+
+	recorder := fixture.do(t, http.MethodPost, "/auth/login", gin.H{"email": "ada@example.com", "password": "password"}, nil)
+	assertStatus(t, recorder, http.StatusOK)
+	login := decode[userResponse](t, recorder)
+	if login.Token != "" || login.CSRFToken == "" {
+		t.Errorf("body token %q, csrf %q; want the token replaced by a CSRF token", login.Token, login.CSRFToken)
+	}
+	cookies := cookiesByName(recorder)
+	if cookies["session"] == nil || cookies["session"].Value == "" || !cookies["session"].HttpOnly {
+		t.Errorf("session cookie = %+v, want an HttpOnly cookie with the token", cookies["session"])
+	}
+
+	recorder = fixture.do(t, http.MethodPost, "/auth/logout", nil, nil)
+	assertStatus(t, recorder, http.StatusOK)
+	cookies = cookiesByName(recorder)
+	for _, name := range []string{"session", "csrf_token"} {
+		if cookies[name] == nil || cookies[name].MaxAge >= 0 {
+			t.Errorf("logout did not expire the %s cookie: %+v", name, cookies[name])
+		}
+	}
+}
+
+func TestLogout(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/logout", nil, nil), http.StatusOK)
+}
+
+func cookiesByName(recorder *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range recorder.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	return cookies
+}
+
+func TestGetUser(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	ada := fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+
+	tests := []struct {
+		name string
+		id   string
+		want int
+	}{
+		{"existing user", ada.ID.Hex(), http.StatusOK},
+		{"unknown user", "5f0000000000000000000000", http.StatusNotFound},
+		{"malformed ID", "not-an-id", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := fixture.do(t, http.MethodGet, "/users/"+test.id, nil, ada)
+			assertStatus(t, recorder, test.want)
+			if test.want == http.StatusOK {
+				if user := decode[userResponse](t, recorder).User; user.Email != "ada@example.com" {
+					t.Errorf("user = %+v", user)
+				}
+				if strings.Contains(recorder.Body.String(), "password") {
+					t.Error("the response includes the password hash")
+				}
+			}
+		})
+	}
+}
+
+func TestGetCurrentUser(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	ada := fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+
+	recorder := fixture.do(t, http.MethodGet, "/users/me", nil, ada)
+	assertStatus(t, recorder, http.StatusOK)
+	if user := decode[userResponse](t, recorder).User; user.ID != ada.ID {
+		t.Errorf("user = %+v, want %s", user, ada.ID.Hex())
+	}
+
+	assertStatus(t, fixture.do(t, http.MethodGet, "/users/me", nil, nil), http.StatusUnauthorized)
+
+	if err := fixture.userRepo.DeleteByID(context.Background(), ada.ID.Hex()); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+	assertStatus(t, fixture.do(t, http.MethodGet, "/users/me", nil, ada), http.StatusNotFound)
+}
+
+func TestGetAllUsers(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	admin := fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+	fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+
+	recorder := fixture.do(t, http.MethodGet, "/admin/users", nil, admin)
+	assertStatus(t, recorder, http.StatusOK)
+	if users := decode[struct{ Users []*model.User }](t, recorder).Users; len(users) != 2 {
+		t.Errorf("users = %d, want 2", len(users))
+	}
+}
+
+func TestGetDuplicateEmails(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	admin := fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+
+	recorder := fixture.do(t, http.MethodGet, "/admin/users/duplicates", nil, admin)
+	assertStatus(t, recorder, http.StatusOK)
+	if duplicates := decode[struct{ Duplicates []repository.DuplicateEmail }](t, recorder).Duplicates; len(duplicates) != 0 {
+		t.Errorf("duplicates = %+v, want none", duplicates)
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		asAdmin   bool
+		target    string
+		body      any
+		want      int
+		wantName  string
+		wantEmail string
+	}{
+		{name: "own account", target: "ada", body: gin.H{"name": "Ada L."}, want: http.StatusOK, wantName: "Ada L.", wantEmail: "ada@example.com"},
+		{name: "own email", target: "ada", body: gin.H{"email": "Ada.L@Example.com"}, want: http.StatusOK, wantName: "Ada", wantEmail: "ada.l@example.com"},
+		{name: "someone else's account", target: "grace", body: gin.H{"name": "Hacked"}, want: http.StatusForbidden},
+		{name: "admin updates anyone", asAdmin: true, target: "grace", body: gin.H{"name": "Grace H."}, want: http.StatusOK, wantName: "Grace H.", wantEmail: "grace@example.com"},
+		{name: "malformed body", target: "ada", body: "{", want: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, nil)
+			users := map[string]*model.User{
+				"ada":   fixture.addUser(t, "Ada", "ada@example.com", enums.User),
+				"grace": fixture.addUser(t, "Grace", "grace@example.com", enums.User),
+			}
+			actor := users["ada"]
+			if test.asAdmin {
+				actor = fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+			}
+
+			recorder := fixture.do(t, http.MethodPut, "/users/"+users[test.target].ID.Hex(), test.body, actor)
+			assertStatus(t, recorder, test.want)
+			if test.want != http.StatusOK {
+				return
+			}
+			stored, _ := fixture.userRepo.FindByID(context.Background(), users[test.target].ID.Hex())
+			if stored.Name != test.wantName || stored.Email != test.wantEmail {
+				t.Errorf("stored user = %s <%s>, want %s <%s>", stored.Name, stored.Email, test.wantName, test.wantEmail)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		asAdmin bool
+		target  string
+		want    int
+	}{
+		{name: "own account", target: "ada", want: http.StatusOK},
+		{name: "someone else's account", target: "grace", want: http.StatusForbidden},
+		{name: "admin deletes anyone", asAdmin: true, target: "grace", want: http.StatusOK},
+		{name: "admin deletes an unknown user", asAdmin: true, target: "missing", want: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, nil)
+			ids := map[string]string{
+				"ada":     fixture.addUser(t, "Ada", "ada@example.com", enums.User).ID.Hex(),
+				"grace":   fixture.addUser(t, "Grace", "grace@example.com", enums.User).ID.Hex(),
+				"missing": "5f0000000000000000000000",
+			}
+			actor, _ := fixture.userRepo.FindByID(context.Background(), ids["ada"])
+			if test.asAdmin {
+				actor = fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+			}
+
+			assertStatus(t, fixture.do(t, http.MethodDelete, "/users/"+ids[test.target], nil, actor), test.want)
+			remaining, _ := fixture.userRepo.FindByID(context.Background(), ids[test.target])
+			if deleted := remaining == nil; deleted != (test.want == http.StatusOK || test.target == "missing") {
+				t.Errorf("user deleted = %v after status %d", deleted, test.want)
+			}
+		})
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		actorRole enums.Role
+		self      bool
+		targetID  string
+		body      any
+		want      int
+	}{
+		{name: "admin grants admin", actorRole: enums.Admin, body: gin.H{"role": "ADMIN"}, want: http.StatusOK},
+		{name: "institution admin grants admin", actorRole: enums.InstitutionAdmin, body: gin.H{"role": "ADMIN"}, want: http.StatusForbidden},
+		{name: "own role", actorRole: enums.Admin, self: true, body: gin.H{"role": "USER"}, want: http.StatusForbidden},
+		{name: "unknown user", actorRole: enums.Admin, targetID: "5f0000000000000000000000", body: gin.H{"role": "GUEST"}, want: http.StatusNotFound},
+		{name: "invalid role", actorRole: enums.Admin, body: gin.H{"role": "OWNER"}, want: http.StatusBadRequest},
+		{name: "missing role", actorRole: enums.Admin, body: gin.H{}, want: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, nil)
+			actor := fixture.addUser(t, "Actor", "actor@example.com", test.actorRole)
+			target := fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+			targetID := target.ID.Hex()
+			switch {
+			case test.self:
+				targetID = actor.ID.Hex()
+			case test.targetID != "":
+				targetID = test.targetID
+			}
+
+			recorder := fixture.do(t, http.MethodPut, "/admin/users/"+targetID+"/role", test.body, actor)
+			assertStatus(t, recorder, test.want)
+			if test.want == http.StatusOK {
+				if user := decode[userResponse](t, recorder).User; user.Role != enums.Admin {
+					t.Errorf("role = %s, want ADMIN", user.Role)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/dtos/response"
+	"Student-Assistant-App/src/service"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes caps the size of an uploaded import file.
+const maxImportBytes = 5 << 20
+
+// Import users from CSV or JSON
+//
+// The body is a JSON array of users, a CSV document (Content-Type text/csv)
+// or a multipart upload with the file in the "file" field. CSV files need a
+// header row naming the name, email, role and password columns; only email
+// is required. Existing users can only be renamed: rows that change their
+// role or set their password are rejected. With ?dry_run=true nothing is
+// written, and with ?invite=true newly created users receive a set-password
+// code by email. Those emails are queued, so the response does not wait on
+// the mail server.
+func (uc *UserController) ImportUsers(ctx *gin.Context) {
+	dryRun, _ := strconv.ParseBool(ctx.Query("dry_run"))
+	invite, _ := strconv.ParseBool(ctx.Query("invite"))
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+	rows, err := readImportRows(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid import file: " + err.Error()})
+		return
+	}
+
+	result, err := uc.userService.ImportUsers(ctx.Request.Context(), rows, dryRun)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrTooManyImportRows) {
+			statusCode = http.StatusRequestEntityTooLarge
+		}
+		ctx.JSON(statusCode, gin.H{"message": err.Error()})
+		return
+	}
+
+	if invite && !dryRun {
+		uc.queueImportInvites(ctx.Request.Context(), result.Rows)
+	}
+
+	result.Message = "Users imported successfully"
+	if dryRun {
+		result.Message = "Dry run completed; no users were changed"
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// queueImportInvites queues one job that emails every created user a
+// set-password code. Delivery failures are logged by the queue.
+func (uc *UserController) queueImportInvites(ctx context.Context, rows []response.ImportUserResult) {
+	var invitees []*response.ImportUserResult
+	for i := range rows {
+		if rows[i].Action == service.ImportActionCreate {
+			invitees = append(invitees, &rows[i])
+		}
+	}
+	if len(invitees) == 0 {
+		return
+	}
+
+	emails := make([]string, len(invitees))
+	for i, row := range invitees {
+		emails[i] = row.Email
+	}
+	otpService := uc.otpService
+	queued := uc.jobs.Enqueue(ctx, func(ctx context.Context) error {
+		var errs []error
+		for _, email := range emails {
+			if err := otpService.GenerateAndSendOTP(ctx, email, "set_password"); err != nil {
+				errs = append(errs, fmt.Errorf("invite %s: %w", email, err))
+			}
+		}
+		return errors.Join(errs...)
+	})
+
+	for _, row := range invitees {
+		if queued {
+			row.Invited = true
+		} else {
+			row.Error = "user created but the invitation could not be queued; resend it later"
+		}
+	}
+}
+
+// Export users as CSV, filtered by role, institution_id and search
+func (uc *UserController) ExportUsers(ctx *gin.Context) {
+	filter := repository.UserFilter{
+		Role:          enums.Role(strings.ToUpper(ctx.Query("role"))),
+		InstitutionID: ctx.Query("institution_id"),
+		Search:        ctx.Query("search"),
+	}
+	if filter.Role != "" && !filter.Role.IsValid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid role"})
+		return
+	}
+
+	users, err := uc.userService.FindUsers(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve users"})
+		return
+	}
+
+	// Build the whole file first so a write error can still become a 500.
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	writer.Write([]string{"id", "name", "email", "role", "institution_id"})
+	for _, user := range users {
+		writer.Write([]string{user.ID.Hex(), csvCell(user.Name), csvCell(user.Email), string(user.Role), csvCell(user.InstitutionID)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to export users"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="users.csv"`)
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", body.Bytes())
+}
+
+// csvCell stops spreadsheets from evaluating user-supplied values as
+// formulas by prefixing cells that would start one with a quote.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// Set password with an invitation or password reset OTP
+func (uc *UserController) SetPassword(ctx *gin.Context) {
+	var setPasswordRequest request.SetPasswordRequest
+	if err := ctx.ShouldBindJSON(&setPasswordRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Bad request"})
+		return
+	}
+
+	purpose := setPasswordRequest.GetPurpose()
+	if purpose == "" {
+		purpose = "set_password"
+	}
+	if purpose != "set_password" && purpose != "password_reset" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid OTP purpose"})
+		return
+	}
+
+	err := uc.otpService.VerifyOTP(ctx.Request.Context(), setPasswordRequest.Email, setPasswordRequest.OTPCode, purpose)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired OTP"})
+		return
+	}
+
+	if err := uc.userService.SetPassword(ctx.Request.Context(), setPasswordRequest.Email, setPasswordRequest.Password); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+}
+
+func readImportRows(ctx *gin.Context) ([]request.ImportUserRow, error) {
+	contentType := ctx.ContentType()
+	if contentType == "multipart/form-data" {
+		file, header, err := ctx.Request.FormFile("file")
+		if err != nil {
+			return nil, errors.New(`upload the file in the "file" field`)
+		}
+		defer file.Close()
+		if strings.HasSuffix(strings.ToLower(header.Filename), ".json") {
+			return decodeImportJSON(file)
+		}
+		return decodeImportCSV(file)
+	}
+	if contentType == "text/csv" {
+		return decodeImportCSV(ctx.Request.Body)
+	}
+	return decodeImportJSON(ctx.Request.Body)
+}
+
+func decodeImportJSON(reader io.Reader) ([]request.ImportUserRow, error) {
+	var rows []request.ImportUserRow
+	if err := json.NewDecoder(reader).Decode(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func decodeImportCSV(reader io.Reader) ([]request.ImportUserRow, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, errors.New(`header must include an "email" column`)
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []request.ImportUserRow
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == service.MaxImportRows {
+			return nil, service.ErrTooManyImportRows
+		}
+		rows = append(rows, request.ImportUserRow{
+			Name:     field(record, "name"),
+			Email:    field(record, "email"),
+			Role:     enums.Role(strings.ToUpper(field(record, "role"))),
+			Password: field(record, "password"),
+		})
+	}
+}
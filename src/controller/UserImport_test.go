@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/dtos/response"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestImportUsers(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		want        int
+		wantActions []string
+		wantStored  []string
+		wantInvited []string
+	}{
+		{
+			name:        "JSON",
+			contentType: "application/json",
+			body:        `[{"name":"Grace","email":"Grace@Example.com","role":"GUEST"},{"email":"ada@example.com","role":"INSTITUTION_ADMIN"},{"email":"bad"}]`,
+			want:        http.StatusOK,
+			wantActions: []string{"create", "error", "error"},
+			wantStored:  []string{"admin@example.com", "ada@example.com", "grace@example.com"},
+		},
+		{
+			name:        "CSV with a byte order mark",
+			contentType: "text/csv",
+			body:        "\ufeffName, Email, Role\nGrace,grace@example.com,user\nAda L.,ada@example.com,\n",
+			want:        http.StatusOK,
+			wantActions: []string{"create", "update"},
+			wantStored:  []string{"admin@example.com", "ada@example.com", "grace@example.com"},
+		},
+		{
+			name:        "dry run",
+			query:       "?dry_run=true",
+			contentType: "text/csv",
+			body:        "name,email\nGrace,grace@example.com\n",
+			want:        http.StatusOK,
+			wantActions: []string{"create"},
+			wantStored:  []string{"admin@example.com", "ada@example.com"},
+		},
+		{
+			name:        "invite new users",
+			query:       "?invite=true",
+			contentType: "text/csv",
+			body:        "name,email\nGrace,grace@example.com\nAda,ada@example.com\n",
+			want:        http.StatusOK,
+			wantActions: []string{"create", "update"},
+			wantStored:  []string{"admin@example.com", "ada@example.com", "grace@example.com"},
+			wantInvited: []string{"grace@example.com"},
+		},
+		{
+			name:        "existing user's role or password",
+			contentType: "application/json",
+			body:        `[{"email":"ada@example.com","role":"GUEST"},{"email":"admin@example.com","password":"hunter2"}]`,
+			want:        http.StatusOK,
+			wantActions: []string{"error", "error"},
+			wantStored:  []string{"admin@example.com", "ada@example.com"},
+		},
+		{
+			name:        "repeated email",
+			contentType: "application/json",
+			body:        `[{"name":"Grace","email":"grace@example.com"},{"name":"Grace","email":"GRACE@example.com"}]`,
+			want:        http.StatusOK,
+			wantActions: []string{"create", "error"},
+			wantStored:  []string{"admin@example.com", "ada@example.com", "grace@example.com"},
+		},
+		{
+			name:        "CSV without an email column",
+			contentType: "text/csv",
+			body:        "name\nGrace\n",
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "malformed JSON",
+			contentType: "application/json",
+			body:        `{"email":"grace@example.com"}`,
+			want:        http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, nil)
+			admin := fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+			fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/import"+test.query, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+			recorder := fixture.serve(req, admin)
+			assertStatus(t, recorder, test.want)
+			if test.want != http.StatusOK {
+				return
+			}
+
+			result := decode[response.ImportUsersResponse](t, recorder)
+			var actions, invited []string
+			for _, row := range result.Rows {
+				actions = append(actions, row.Action)
+				if row.Invited {
+					invited = append(invited, row.Email)
+				}
+			}
+			if !slices.Equal(actions, test.wantActions) {
+				t.Errorf("actions = %v, want %v (%+v)", actions, test.wantActions, result.Rows)
+			}
+			if !slices.Equal(invited, test.wantInvited) {
+				t.Errorf("invited = %v, want %v", invited, test.wantInvited)
+			}
+			if fixture.emails.Sent() != 0 {
+				t.Error("invitations were sent before the response")
+			}
+			fixture.runJobs()
+			for _, email := range test.wantInvited {
+				if fixture.emails.LastCode(email, "set_password") == "" {
+					t.Errorf("no set_password code sent to %s", email)
+				}
+			}
+
+			users, _ := fixture.userRepo.FindAll(context.Background())
+			var stored []string
+			for _, user := range users {
+				stored = append(stored, user.Email)
+			}
+			if !slices.Equal(stored, test.wantStored) {
+				t.Errorf("stored users = %v, want %v", stored, test.wantStored)
+			}
+		})
+	}
+}
+
+func TestImportUsersInviteQueueFull(t *testing.T) {
+	fixture := newUserControllerFixture(t, func(cfg *config.Config) { cfg.Email.OutboxSize = 0 })
+	admin := fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/import?invite=true", strings.NewReader("name,email\nGrace,grace@example.com\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	recorder := fixture.serve(req, admin)
+	assertStatus(t, recorder, http.StatusOK)
+
+	result := decode[response.ImportUsersResponse](t, recorder)
+	if row := result.Rows[0]; row.Action != "create" || row.Invited || row.Error == "" {
+		t.Errorf("row = %+v, want created and reported as not invited", row)
+	}
+}
+
+func TestImportUsersMultipart(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	admin := fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "users.json")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	file.Write([]byte(`[{"name":"Grace","email":"grace@example.com"}]`))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	recorder := fixture.serve(req, admin)
+	assertStatus(t, recorder, http.StatusOK)
+	if result := decode[response.ImportUsersResponse](t, recorder); result.Created != 1 {
+		t.Errorf("created = %d, want 1: %+v", result.Created, result.Rows)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/users/import", strings.NewReader("--x--\r\n"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	assertStatus(t, fixture.serve(req, admin), http.StatusBadRequest)
+}
+
+func TestExportUsers(t *testing.T) {
+	fixture := newUserControllerFixture(t, nil)
+	admin := fixture.addUser(t, "Admin", "admin@example.com", enums.Admin)
+	fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+	fixture.addUser(t, "Grace, PhD", "grace@example.com", enums.User)
+	fixture.addUser(t, "=HYPERLINK(\"http://evil.example\")", "mallory@example.com", enums.User)
+
+	tests := []struct {
+		name       string
+		query      string
+		want       int
+		wantEmails []string
+	}{
+		{"everyone", "", http.StatusOK, []string{"ada@example.com", "admin@example.com", "grace@example.com", "mallory@example.com"}},
+		{"by role, ignoring case", "?role=user", http.StatusOK, []string{"ada@example.com", "grace@example.com", "mallory@example.com"}},
+		{"by search", "?search=phd", http.StatusOK, []string{"grace@example.com"}},
+		{"invalid role", "?role=owner", http.StatusBadRequest, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := fixture.do(t, http.MethodGet, "/admin/users/export"+test.query, nil, admin)
+			assertStatus(t, recorder, test.want)
+			if test.want != http.StatusOK {
+				return
+			}
+			if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+				t.Errorf("Content-Type = %q", contentType)
+			}
+
+			records, err := csv.NewReader(recorder.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("reading CSV: %v", err)
+			}
+			if !slices.Equal(records[0], []string{"id", "name", "email", "role", "institution_id"}) {
+				t.Errorf("header = %v", records[0])
+			}
+			var emails []string
+			for _, record := range records[1:] {
+				emails = append(emails, record[2])
+				if strings.HasPrefix(record[1], "=") {
+					t.Errorf("name %q is exported as a formula", record[1])
+				}
+			}
+			if !slices.Equal(emails, test.wantEmails) {
+				t.Errorf("emails = %v, want %v", emails, test.wantEmails)
+			}
+		})
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose string
+		code    func(fixture *userControllerFixture) string
+		want    int
+	}{
+		{
+			name:    "password reset code",
+			purpose: "password_reset",
+			code: func(fixture *userControllerFixture) string {
+				return fixture.emails.LastCode("ada@example.com", "password_reset")
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:    "wrong code",
+			purpose: "password_reset",
+			code:    func(*userControllerFixture) string { return "000000" },
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name:    "code for another purpose",
+			purpose: "set_password",
+			code: func(fixture *userControllerFixture) string {
+				return fixture.emails.LastCode("ada@example.com", "password_reset")
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:    "invalid purpose",
+			purpose: "login",
+			code:    func(*userControllerFixture) string { return "000000" },
+			want:    http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserControllerFixture(t, nil)
+			fixture.addUser(t, "Ada", "ada@example.com", enums.User)
+			fixture.sendCode(t, "ada@example.com", "password_reset")
+
+			recorder := fixture.do(t, http.MethodPost, "/auth/set-password", gin.H{
+				"email":    "ada@example.com",
+				"otp_code": test.code(fixture),
+				"password": "new-password",
+				"purpose":  test.purpose,
+			}, nil)
+			assertStatus(t, recorder, test.want)
+
+			login := fixture.do(t, http.MethodPost, "/auth/login", gin.H{"email": "ada@example.com", "password": "new-password"}, nil)
+			if changed := login.Code == http.StatusOK; changed != (test.want == http.StatusOK) {
+				t.Errorf("password changed = %v after status %d", changed, test.want)
+			}
+		})
+	}
+
+	fixture := newUserControllerFixture(t, nil)
+	assertStatus(t, fixture.do(t, http.MethodPost, "/auth/set-password", gin.H{"email": "ada@example.com"}, nil), http.StatusBadRequest)
+}
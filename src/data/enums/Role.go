@@ -3,16 +3,25 @@ package enums
 type Role string
 
 const (
+	// Admin is a global administrator across all institutions.
 	Admin Role = "ADMIN"
-	User  Role = "USER"
-	Guest Role = "GUEST"
+	// InstitutionAdmin administers the users of a single institution.
+	InstitutionAdmin Role = "INSTITUTION_ADMIN"
+	User             Role = "USER"
+	Guest            Role = "GUEST"
 )
 
 func (r Role) IsValid() bool {
 	switch r {
-	case Admin, User, Guest:
+	case Admin, InstitutionAdmin, User, Guest:
 		return true
 	default:
 		return false
 	}
-}
\ No newline at end of file
+}
+
+// IsAdmin reports whether the role may use the admin API, either globally
+// or within its own institution.
+func (r Role) IsAdmin() bool {
+	return r == Admin || r == InstitutionAdmin
+}
@@ -0,0 +1,22 @@
+package enums
+
+// Scope limits what a personal API key may do on behalf of its owner.
+type Scope string
+
+const (
+	// ScopeRead allows safe requests (GET and HEAD).
+	ScopeRead Scope = "read"
+	// ScopeWrite allows requests that change data.
+	ScopeWrite Scope = "write"
+	// ScopeAdmin allows the admin API, for owners who are admins.
+	ScopeAdmin Scope = "admin"
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeRead, ScopeWrite, ScopeAdmin:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,216 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	collectionName = "schema_migrations"
+	lockID         = "lock"
+	// staleLockAge is how long a lock may be held before another process is
+	// allowed to take it over, so a crashed run doesn't block forever.
+	staleLockAge = 10 * time.Minute
+)
+
+var ErrLocked = errors.New("migrations are locked by another process")
+
+// ErrDeferred is returned, usually wrapped with the reason, by a migration
+// that cannot be applied yet. It is left pending and retried by the next Up,
+// and the migrations after it still run.
+var ErrDeferred = errors.New("migration deferred")
+
+// Migration is one versioned schema or data change. Down may be nil for
+// changes that cannot be reverted.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+type record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+type Status struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+type Runner struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+func NewRunner(db *mongo.Database, migrations []Migration, logger *slog.Logger) *Runner {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &Runner{
+		db:         db,
+		collection: db.Collection(collectionName),
+		migrations: sorted,
+		logger:     logger,
+	}
+}
+
+// Up applies every pending migration in version order and returns how many
+// were applied.
+func (r *Runner) Up(ctx context.Context) (int, error) {
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range r.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		r.logger.InfoContext(ctx, "applying migration", "version", migration.Version, "description", migration.Description)
+		err := migration.Up(ctx, r.db)
+		if errors.Is(err, ErrDeferred) {
+			r.logger.WarnContext(ctx, "migration deferred", "version", migration.Version, "description", migration.Description, "reason", err)
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		_, err = r.collection.InsertOne(ctx, record{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		})
+		if err != nil {
+			return count, fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down reverts the most recently applied migrations, up to steps of them.
+func (r *Runner) Down(ctx context.Context, steps int) (int, error) {
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(r.migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := r.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return count, fmt.Errorf("migration %d (%s) is irreversible", migration.Version, migration.Description)
+		}
+
+		r.logger.InfoContext(ctx, "reverting migration", "version", migration.Version, "description", migration.Description)
+		if err := migration.Down(ctx, r.db); err != nil {
+			return count, fmt.Errorf("reverting migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+			return count, fmt.Errorf("unrecording migration %d: %w", migration.Version, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, migration := range r.migrations {
+		status := Status{
+			Version:     migration.Version,
+			Description: migration.Description,
+		}
+		if rec, ok := applied[migration.Version]; ok {
+			appliedAt := rec.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]record, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	applied := make(map[int]record)
+	for cursor.Next(ctx) {
+		var rec record
+		if err := cursor.Decode(&rec); err != nil {
+			return nil, err
+		}
+		applied[rec.Version] = rec
+	}
+	return applied, cursor.Err()
+}
+
+// lock stores a lock document so that only one process migrates at a time.
+func (r *Runner) lock(ctx context.Context) (func(), error) {
+	now := time.Now()
+	_, err := r.collection.InsertOne(ctx, bson.M{"_id": lockID, "locked_at": now})
+	if mongo.IsDuplicateKeyError(err) {
+		result, takeErr := r.collection.UpdateOne(ctx,
+			bson.M{"_id": lockID, "locked_at": bson.M{"$lt": now.Add(-staleLockAge)}},
+			bson.M{"$set": bson.M{"locked_at": now}},
+		)
+		if takeErr != nil {
+			return nil, takeErr
+		}
+		if result.ModifiedCount == 0 {
+			return nil, ErrLocked
+		}
+		r.logger.WarnContext(ctx, "took over stale migration lock")
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if _, err := r.collection.DeleteOne(context.Background(), bson.M{"_id": lockID}); err != nil {
+			r.logger.Error("failed to release migration lock", "error", err)
+		}
+	}, nil
+}
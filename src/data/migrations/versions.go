@@ -0,0 +1,344 @@
+package migrations
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All returns every migration known to this build. New migrations are
+// appended with the next version number; applied versions must never change.
+func All(cfg *config.Config, logger *slog.Logger) []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "create OTP expiry and active-code indexes",
+			Up:          createOTPIndexes,
+			Down:        dropIndexes("otps", "expires_at_1", "email_purpose_active"),
+		},
+		{
+			Version:     2,
+			Description: "hash legacy plaintext OTP codes",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := repository.NewOTPRepositoryImpl(db).MigrateLegacyCodes(ctx, func(code string) string {
+					return utils.HashOTP(code, cfg.OTP.Secret)
+				})
+				return err
+			},
+		},
+		{
+			Version:     3,
+			Description: "create unique case-insensitive index on users.email",
+			Up:          createUserEmailIndex,
+			Down:        dropIndexes("users", "email_unique"),
+		},
+		{
+			Version:     4,
+			Description: "normalize stored user emails",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return normalizeUserEmails(ctx, db, logger)
+			},
+		},
+		{
+			Version:     5,
+			Description: "create institution domain and user institution indexes",
+			Up:          createInstitutionIndexes,
+			Down: func(ctx context.Context, db *mongo.Database) error {
+				if err := dropIndexes("users", "institution_id_1")(ctx, db); err != nil {
+					return err
+				}
+				return dropIndexes("institutions", "domains_unique")(ctx, db)
+			},
+		},
+		{
+			Version:     6,
+			Description: "seed institutions from signup configuration",
+			// InstitutionService.EnsureInstitutions creates the configured
+			// institutions on every start, so there is nothing left to do.
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return nil
+			},
+		},
+		{
+			Version:     7,
+			Description: "create invitation token and expiry indexes",
+			Up:          createInvitationIndexes,
+			Down:        dropIndexes("invitations", "token_hash_unique", "expires_at_1"),
+		},
+		{
+			Version:     8,
+			Description: "assign the default role to users stored without one",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				result, err := db.Collection("users").UpdateMany(ctx,
+					bson.M{"$or": bson.A{bson.M{"role": ""}, bson.M{"role": bson.M{"$exists": false}}}},
+					bson.M{"$set": bson.M{"role": cfg.Signup.DefaultRole}},
+				)
+				if err != nil {
+					return err
+				}
+				logger.InfoContext(ctx, "assigned default role", "users", result.ModifiedCount, "role", cfg.Signup.DefaultRole)
+				return nil
+			},
+		},
+		{
+			Version:     9,
+			Description: "create unique provider subject index on identities",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection("identities").Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+						Options: options.Index().SetName("provider_subject_unique").SetUnique(true),
+					},
+					{
+						Keys: bson.D{{Key: "user_id", Value: 1}},
+					},
+				})
+				return err
+			},
+			Down: dropIndexes("identities", "provider_subject_unique", "user_id_1"),
+		},
+		{
+			Version:     10,
+			Description: "expire retired JWT signing keys",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection("signing_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				})
+				return err
+			},
+			Down: dropIndexes("signing_keys", "expires_at_1"),
+		},
+		{
+			Version:     11,
+			Description: "create API key prefix, owner and expiry indexes",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection("api_keys").Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "prefix", Value: 1}},
+						Options: options.Index().SetName("prefix_unique").SetUnique(true),
+					},
+					{
+						Keys: bson.D{{Key: "user_id", Value: 1}},
+					},
+					{
+						// Expired keys stay listed for a while so owners can see
+						// what stopped working.
+						Keys:    bson.D{{Key: "expires_at", Value: 1}},
+						Options: options.Index().SetExpireAfterSeconds(int32((30 * 24 * time.Hour).Seconds())),
+					},
+				})
+				return err
+			},
+			Down: dropIndexes("api_keys", "prefix_unique", "user_id_1", "expires_at_1"),
+		},
+	}
+}
+
+func createOTPIndexes(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("otps")
+
+	// Codes issued before the unique index existed may still be marked unused,
+	// and resends left several live codes per (email, purpose). Retire the
+	// expired ones and all but the newest of the rest so they cannot block
+	// the index build.
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"used": false, "expires_at": bson.M{"$lte": time.Now()}},
+		bson.M{"$set": bson.M{"used": true}},
+	)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"used": false}, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetProjection(bson.M{"email": 1, "purpose": 1}))
+	if err != nil {
+		return err
+	}
+	var unused []unusedOTP
+	if err := cursor.All(ctx, &unused); err != nil {
+		return err
+	}
+	if superseded := supersededOTPs(unused); len(superseded) > 0 {
+		_, err = collection.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": superseded}},
+			bson.M{"$set": bson.M{"used": true}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			// At most one active OTP per (email, purpose).
+			Keys: bson.D{{Key: "email", Value: 1}, {Key: "purpose", Value: 1}},
+			Options: options.Index().
+				SetName("email_purpose_active").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"used": false}),
+		},
+	})
+	return err
+}
+
+type unusedOTP struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Email   string             `bson:"email"`
+	Purpose string             `bson:"purpose"`
+}
+
+// supersededOTPs returns the IDs of every code but the first for each
+// (email, purpose), given codes ordered newest first.
+func supersededOTPs(codes []unusedOTP) []primitive.ObjectID {
+	type key struct{ email, purpose string }
+	newest := make(map[key]bool)
+	var superseded []primitive.ObjectID
+	for _, code := range codes {
+		k := key{code.Email, code.Purpose}
+		if newest[k] {
+			superseded = append(superseded, code.ID)
+			continue
+		}
+		newest[k] = true
+	}
+	return superseded
+}
+
+func createUserEmailIndex(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("users")
+
+	duplicates, err := repository.NewUserRepositoryImpl(db).FindDuplicateEmails(ctx)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		emails := make([]string, 0, len(duplicates))
+		for _, duplicate := range duplicates {
+			emails = append(emails, duplicate.Email)
+		}
+		// Failing here would keep the server, and with it the duplicate
+		// accounts endpoint, from starting. Wait for an admin to resolve them.
+		return fmt.Errorf("%w: resolve duplicate accounts first (GET /api/v1/admin/users/duplicates): %s",
+			ErrDeferred, strings.Join(emails, ", "))
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().
+			SetName("email_unique").
+			SetUnique(true).
+			SetCollation(repository.EmailCollation),
+	})
+	return err
+}
+
+// normalizeUserEmails rewrites user emails into canonical form. Accounts
+// whose emails collide once normalised are left untouched and reported so an
+// administrator can resolve them (see GET /api/v1/admin/users/duplicates).
+func normalizeUserEmails(ctx context.Context, db *mongo.Database, logger *slog.Logger) error {
+	userRepo := repository.NewUserRepositoryImpl(db)
+	duplicates, err := userRepo.FindDuplicateEmails(ctx)
+	if err != nil {
+		return err
+	}
+
+	conflicting := make(map[primitive.ObjectID]bool)
+	for _, duplicate := range duplicates {
+		ids := make([]string, 0, len(duplicate.Users))
+		for _, user := range duplicate.Users {
+			conflicting[user.ID] = true
+			ids = append(ids, user.ID.Hex())
+		}
+		logger.WarnContext(ctx, "duplicate accounts need admin resolution", "email", duplicate.Email, "user_ids", ids)
+	}
+
+	users, err := userRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	collection := db.Collection("users")
+	for _, user := range users {
+		if conflicting[user.ID] {
+			continue
+		}
+		normalized, err := utils.NormalizeEmail(user.Email)
+		if err != nil {
+			logger.WarnContext(ctx, "cannot normalize invalid email", "user_id", user.ID.Hex(), "email", user.Email)
+		}
+		if normalized == user.Email {
+			continue
+		}
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"email": normalized}})
+		if mongo.IsDuplicateKeyError(err) {
+			// Another account already has the canonical form, e.g. the
+			// punycode spelling of a Unicode domain.
+			logger.WarnContext(ctx, "duplicate accounts need admin resolution", "email", normalized, "user_ids", []string{user.ID.Hex()})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("normalizing email of user %s: %w", user.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func createInstitutionIndexes(ctx context.Context, db *mongo.Database) error {
+	// A domain may belong to at most one institution.
+	_, err := db.Collection("institutions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "domains", Value: 1}},
+		Options: options.Index().SetName("domains_unique").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "institution_id", Value: 1}},
+	})
+	return err
+}
+
+func createInvitationIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("invitations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetName("token_hash_unique").SetUnique(true),
+		},
+		{
+			// Keep expired invitations around for a month so admins can see
+			// who never responded.
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32((30 * 24 * time.Hour).Seconds())),
+		},
+	})
+	return err
+}
+
+func dropIndexes(collectionName string, names ...string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		indexes := db.Collection(collectionName).Indexes()
+		for _, name := range names {
+			if _, err := indexes.DropOne(ctx, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
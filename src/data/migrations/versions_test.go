@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSupersededOTPs(t *testing.T) {
+	ids := make([]primitive.ObjectID, 6)
+	for i := range ids {
+		ids[i] = primitive.NewObjectID()
+	}
+
+	tests := []struct {
+		name  string
+		codes []unusedOTP
+		want  []primitive.ObjectID
+	}{
+		{name: "no codes"},
+		{
+			name:  "one code per email and purpose",
+			codes: []unusedOTP{{ids[0], "a@uni.edu", "login"}, {ids[1], "a@uni.edu", "signup"}, {ids[2], "b@uni.edu", "login"}},
+		},
+		{
+			// The pre-index resend flow left every earlier code unused.
+			name: "resent codes",
+			codes: []unusedOTP{
+				{ids[0], "a@uni.edu", "login"},
+				{ids[1], "b@uni.edu", "login"},
+				{ids[2], "a@uni.edu", "login"},
+				{ids[3], "a@uni.edu", "signup"},
+				{ids[4], "a@uni.edu", "login"},
+				{ids[5], "b@uni.edu", "login"},
+			},
+			want: []primitive.ObjectID{ids[2], ids[4], ids[5]},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := supersededOTPs(test.codes); !slices.Equal(got, test.want) {
+				t.Errorf("supersededOTPs = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey is a personal key a user creates for scripts. The key is shown
+// once; only its public prefix and a hash of its secret are stored.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"`
+	SecretHash string             `bson:"secret_hash" json:"-"`
+	Scopes     []enums.Scope      `bson:"scopes" json:"scopes"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+func (k *APIKey) IsExpired() bool {
+	return time.Now().After(k.ExpiresAt)
+}
+
+func (k *APIKey) HasScope(scope enums.Scope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
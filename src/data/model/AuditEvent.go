@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEvent records a security-relevant change, such as a role change,
+// along with who made it.
+type AuditEvent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Action        string             `bson:"action" json:"action"`
+	ActorID       string             `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	TargetID      string             `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	InstitutionID string             `bson:"institution_id,omitempty" json:"institution_id,omitempty"`
+	Details       map[string]string  `bson:"details,omitempty" json:"details,omitempty"`
+	RequestID     string             `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
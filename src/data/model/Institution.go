@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type Institution struct {
+	// ID is a short, URL-safe slug such as "uni-a"; it doubles as the tenant
+	// key stored on users and in tokens.
+	ID        string    `bson:"_id" json:"id"`
+	Name      string    `bson:"name" json:"name"`
+	Domains   []string  `bson:"domains" json:"domains"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+func (req *Institution) SetID(ID string) {
+	req.ID = ID
+}
+func (req *Institution) GetID() string {
+	return req.ID
+}
+func (req *Institution) SetName(Name string) {
+	req.Name = Name
+}
+func (req *Institution) GetName() string {
+	return req.Name
+}
+func (req *Institution) SetDomains(Domains []string) {
+	req.Domains = Domains
+}
+func (req *Institution) GetDomains() []string {
+	return req.Domains
+}
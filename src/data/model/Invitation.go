@@ -0,0 +1,34 @@
+package model
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation lets an admin pre-approve an account. Only a hash of the token
+// is stored; the token itself is sent to the invitee.
+type Invitation struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TokenHash     string             `bson:"token_hash" json:"-"`
+	Email         string             `bson:"email" json:"email"`
+	Role          enums.Role         `bson:"role" json:"role"`
+	InstitutionID string             `bson:"institution_id,omitempty" json:"institution_id,omitempty"`
+	InvitedBy     string             `bson:"invited_by" json:"invited_by"`
+	ExpiresAt     time.Time          `bson:"expires_at" json:"expires_at"`
+	AcceptedAt    *time.Time         `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+func (i *Invitation) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
+
+func (i *Invitation) IsAccepted() bool {
+	return i.AcceptedAt != nil
+}
+
+func (i *Invitation) IsValid() bool {
+	return !i.IsExpired() && !i.IsAccepted()
+}
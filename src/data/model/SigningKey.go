@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// SigningKey is one asymmetric key used to sign access tokens. The newest
+// active key signs; every unexpired key verifies and is published in the
+// JWKS so tokens stay valid across rotations.
+type SigningKey struct {
+	// ID is the key ID carried in the "kid" header of tokens it signs.
+	ID        string `bson:"_id" json:"kid"`
+	Algorithm string `bson:"algorithm" json:"alg"`
+	// PrivateKey is the PKCS #8 DER encoding of the private key, encrypted
+	// with the key encryption key and base64 encoded.
+	PrivateKey  string    `bson:"private_key" json:"-"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	ActivatesAt time.Time `bson:"activates_at" json:"activates_at"`
+	ExpiresAt   time.Time `bson:"expires_at" json:"expires_at"`
+}
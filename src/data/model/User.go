@@ -7,11 +7,13 @@ import (
 
 
 type User struct {
-    ID       	primitive.ObjectID 		`bson:"_id,omitempty"json:"id"`
-    Name     	string            		`bson:"name"json:"name"`
-    Email    	string            		`bson:"email"json:"email"`
-    Password 	string            		`bson:"password"json:"-"`
-    Role     	enums.Role          	`bson:"role"json:"role"`
+    ID       	primitive.ObjectID 		`bson:"_id,omitempty" json:"id"`
+    Name     	string            		`bson:"name" json:"name"`
+    Email    	string            		`bson:"email" json:"email"`
+    Password 	string            		`bson:"password" json:"-"`
+    Role     	enums.Role          	`bson:"role" json:"role"`
+    // InstitutionID is assigned from the email domain at signup.
+    InstitutionID string `bson:"institution_id,omitempty" json:"institution_id,omitempty"`
 }
 
 func (req*User) SetUser(ID primitive.ObjectID){
@@ -44,3 +46,9 @@ func (req *User) SetRole(Role enums.Role){
 func (req *User) GetRole() enums.Role{
     return req.Role
 }
+func (req *User) SetInstitutionID(institutionID string){
+    req.InstitutionID = institutionID
+}
+func (req *User) GetInstitutionID() string{
+    return req.InstitutionID
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/metrics"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository interface {
+	Save(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*model.APIKey, error)
+	CountByUserID(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	TouchLastUsed(ctx context.Context, id primitive.ObjectID) error
+	DeleteByIDAndUserID(ctx context.Context, id string, userID primitive.ObjectID) (bool, error)
+}
+
+// APIKeyRepositoryImpl is not tenant-scoped: keys always belong to a single
+// user, and every query names that user or the key's unique prefix.
+type APIKeyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepositoryImpl(database *mongo.Database) APIKeyRepository {
+	return &APIKeyRepositoryImpl{
+		collection: database.Collection("api_keys"),
+	}
+}
+
+func (r *APIKeyRepositoryImpl) Save(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	defer metrics.TimeMongo("api_keys", "save")()
+	key.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	key.ID = result.InsertedID.(primitive.ObjectID)
+	return key, nil
+}
+
+func (r *APIKeyRepositoryImpl) FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	defer metrics.TimeMongo("api_keys", "find_by_prefix")()
+	var key model.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepositoryImpl) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*model.APIKey, error) {
+	defer metrics.TimeMongo("api_keys", "find_by_user_id")()
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []*model.APIKey{}
+	for cursor.Next(ctx) {
+		var key model.APIKey
+		if err := cursor.Decode(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepositoryImpl) CountByUserID(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	defer metrics.TimeMongo("api_keys", "count_by_user_id")()
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+func (r *APIKeyRepositoryImpl) TouchLastUsed(ctx context.Context, id primitive.ObjectID) error {
+	defer metrics.TimeMongo("api_keys", "touch_last_used")()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": time.Now()}})
+	return err
+}
+
+// DeleteByIDAndUserID reports whether a key with the given ID belonged to
+// the user and was deleted.
+func (r *APIKeyRepositoryImpl) DeleteByIDAndUserID(ctx context.Context, id string, userID primitive.ObjectID) (bool, error) {
+	defer metrics.TimeMongo("api_keys", "delete_by_id_and_user_id")()
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/metrics"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AuditRepository interface {
+	Record(ctx context.Context, event *model.AuditEvent) error
+}
+
+// AuditRepositoryImpl is append-only.
+type AuditRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepositoryImpl(database *mongo.Database) AuditRepository {
+	return &AuditRepositoryImpl{
+		collection: database.Collection("audit_events"),
+	}
+}
+
+func (r *AuditRepositoryImpl) Record(ctx context.Context, event *model.AuditEvent) error {
+	defer metrics.TimeMongo("audit_events", "record")()
+	event.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/metrics"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrIdentityLinked is returned by Save when the provider account is
+// already linked to a user.
+var ErrIdentityLinked = errors.New("identity is already linked to a user")
+
+type IdentityRepository interface {
+	Save(ctx context.Context, identity *model.Identity) (*model.Identity, error)
+	FindByProviderAndSubject(ctx context.Context, provider, subject string) (*model.Identity, error)
+	TouchLastLogin(ctx context.Context, id primitive.ObjectID) error
+	DeleteByID(ctx context.Context, id primitive.ObjectID) error
+}
+
+type IdentityRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewIdentityRepositoryImpl(database *mongo.Database) IdentityRepository {
+	return &IdentityRepositoryImpl{
+		collection: database.Collection("identities"),
+	}
+}
+
+func (r *IdentityRepositoryImpl) Save(ctx context.Context, identity *model.Identity) (*model.Identity, error) {
+	defer metrics.TimeMongo("identities", "save")()
+	identity.Email = canonicalEmail(identity.Email)
+	if identity.ID.IsZero() {
+		identity.CreatedAt = time.Now()
+		identity.LastLoginAt = identity.CreatedAt
+		result, err := r.collection.InsertOne(ctx, identity)
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrIdentityLinked
+		}
+		if err != nil {
+			return nil, err
+		}
+		identity.ID = result.InsertedID.(primitive.ObjectID)
+	} else {
+		filter := bson.M{"_id": identity.ID}
+		if _, err := r.collection.ReplaceOne(ctx, filter, identity); err != nil {
+			return nil, err
+		}
+	}
+	return identity, nil
+}
+
+func (r *IdentityRepositoryImpl) FindByProviderAndSubject(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	defer metrics.TimeMongo("identities", "find_by_provider_and_subject")()
+	var identity model.Identity
+	err := r.collection.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepositoryImpl) TouchLastLogin(ctx context.Context, id primitive.ObjectID) error {
+	defer metrics.TimeMongo("identities", "touch_last_login")()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_login_at": time.Now()}})
+	return err
+}
+
+func (r *IdentityRepositoryImpl) DeleteByID(ctx context.Context, id primitive.ObjectID) error {
+	defer metrics.TimeMongo("identities", "delete_by_id")()
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/metrics"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrDuplicateInstitution = errors.New("institution ID or domain already in use")
+
+type InstitutionRepository interface {
+	Create(ctx context.Context, institution *model.Institution) (*model.Institution, error)
+	Save(ctx context.Context, institution *model.Institution) (*model.Institution, error)
+	FindByID(ctx context.Context, id string) (*model.Institution, error)
+	FindByDomains(ctx context.Context, domains []string) ([]*model.Institution, error)
+	FindAll(ctx context.Context) ([]*model.Institution, error)
+	Count(ctx context.Context) (int64, error)
+	DeleteByID(ctx context.Context, id string) error
+}
+
+type InstitutionRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewInstitutionRepositoryImpl(database *mongo.Database) InstitutionRepository {
+	return &InstitutionRepositoryImpl{
+		collection: database.Collection("institutions"),
+	}
+}
+
+// Create inserts a new institution, failing with ErrDuplicateInstitution if
+// the ID or one of the domains is taken.
+func (r *InstitutionRepositoryImpl) Create(ctx context.Context, institution *model.Institution) (*model.Institution, error) {
+	defer metrics.TimeMongo("institutions", "create")()
+	institution.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, institution)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicateInstitution
+	}
+	if err != nil {
+		return nil, err
+	}
+	return institution, nil
+}
+
+// Save replaces an existing institution. Saving one that has been deleted
+// is a no-op.
+func (r *InstitutionRepositoryImpl) Save(ctx context.Context, institution *model.Institution) (*model.Institution, error) {
+	defer metrics.TimeMongo("institutions", "save")()
+	filter := bson.M{"_id": institution.ID}
+	_, err := r.collection.ReplaceOne(ctx, filter, institution)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicateInstitution
+	}
+	if err != nil {
+		return nil, err
+	}
+	return institution, nil
+}
+
+func (r *InstitutionRepositoryImpl) FindByID(ctx context.Context, id string) (*model.Institution, error) {
+	defer metrics.TimeMongo("institutions", "find_by_id")()
+	var institution model.Institution
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&institution)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &institution, nil
+}
+
+// FindByDomains returns the institutions owning any of the given domains.
+func (r *InstitutionRepositoryImpl) FindByDomains(ctx context.Context, domains []string) ([]*model.Institution, error) {
+	defer metrics.TimeMongo("institutions", "find_by_domains")()
+	return r.find(ctx, bson.M{"domains": bson.M{"$in": domains}})
+}
+
+func (r *InstitutionRepositoryImpl) FindAll(ctx context.Context) ([]*model.Institution, error) {
+	defer metrics.TimeMongo("institutions", "find_all")()
+	return r.find(ctx, bson.M{})
+}
+
+func (r *InstitutionRepositoryImpl) Count(ctx context.Context) (int64, error) {
+	defer metrics.TimeMongo("institutions", "count")()
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
+
+func (r *InstitutionRepositoryImpl) DeleteByID(ctx context.Context, id string) error {
+	defer metrics.TimeMongo("institutions", "delete_by_id")()
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *InstitutionRepositoryImpl) find(ctx context.Context, filter bson.M) ([]*model.Institution, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var institutions []*model.Institution
+	for cursor.Next(ctx) {
+		var institution model.Institution
+		if err := cursor.Decode(&institution); err != nil {
+			return nil, err
+		}
+		institutions = append(institutions, &institution)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return institutions, nil
+}
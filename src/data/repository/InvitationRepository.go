@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/metrics"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvitationUnavailable is returned by MarkAccepted when the invitation
+// was already accepted, revoked or has expired.
+var ErrInvitationUnavailable = errors.New("invitation is no longer valid")
+
+type InvitationRepository interface {
+	Save(ctx context.Context, invitation *model.Invitation) (*model.Invitation, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*model.Invitation, error)
+	FindAll(ctx context.Context) ([]*model.Invitation, error)
+	MarkAccepted(ctx context.Context, id primitive.ObjectID) error
+	ReleaseAccepted(ctx context.Context, id primitive.ObjectID) error
+	DeleteByID(ctx context.Context, id string) error
+}
+
+// InvitationRepositoryImpl scopes listing and deletion to the institution
+// in the context. Token lookups are not scoped, since invitees are not yet
+// members of any tenant.
+type InvitationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewInvitationRepositoryImpl(database *mongo.Database) InvitationRepository {
+	return &InvitationRepositoryImpl{
+		collection: database.Collection("invitations"),
+	}
+}
+
+func (r *InvitationRepositoryImpl) Save(ctx context.Context, invitation *model.Invitation) (*model.Invitation, error) {
+	defer metrics.TimeMongo("invitations", "save")()
+	invitation.Email = canonicalEmail(invitation.Email)
+	if invitation.ID.IsZero() {
+		invitation.CreatedAt = time.Now()
+		result, err := r.collection.InsertOne(ctx, invitation)
+		if err != nil {
+			return nil, err
+		}
+		invitation.ID = result.InsertedID.(primitive.ObjectID)
+	} else {
+		filter := scopeFilter(ctx, bson.M{"_id": invitation.ID})
+		if _, err := r.collection.ReplaceOne(ctx, filter, invitation); err != nil {
+			return nil, err
+		}
+	}
+	return invitation, nil
+}
+
+func (r *InvitationRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	defer metrics.TimeMongo("invitations", "find_by_token_hash")()
+	var invitation model.Invitation
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&invitation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *InvitationRepositoryImpl) FindAll(ctx context.Context) ([]*model.Invitation, error) {
+	defer metrics.TimeMongo("invitations", "find_all")()
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, scopeFilter(ctx, bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []*model.Invitation
+	for cursor.Next(ctx) {
+		var invitation model.Invitation
+		if err := cursor.Decode(&invitation); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, &invitation)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// MarkAccepted atomically claims a pending invitation, so a token can only
+// ever create one account.
+func (r *InvitationRepositoryImpl) MarkAccepted(ctx context.Context, id primitive.ObjectID) error {
+	defer metrics.TimeMongo("invitations", "mark_accepted")()
+	now := time.Now()
+	filter := bson.M{
+		"_id":         id,
+		"accepted_at": bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": now},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"accepted_at": now}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvitationUnavailable
+	}
+	return nil
+}
+
+// ReleaseAccepted returns a claimed invitation to pending, for when the
+// account it was claimed for could not be created.
+func (r *InvitationRepositoryImpl) ReleaseAccepted(ctx context.Context, id primitive.ObjectID) error {
+	defer metrics.TimeMongo("invitations", "release_accepted")()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"accepted_at": ""}})
+	return err
+}
+
+func (r *InvitationRepositoryImpl) DeleteByID(ctx context.Context, id string) error {
+	defer metrics.TimeMongo("invitations", "delete_by_id")()
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.DeleteOne(ctx, scopeFilter(ctx, bson.M{"_id": objectID}))
+	return err
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/clock"
+	"Student-Assistant-App/src/data/model"
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OTPRepositoryMemory is an in-memory OTPRepository for tests and local
+// tooling. Expiry follows the given clock. It is safe for concurrent use.
+type OTPRepositoryMemory struct {
+	mu    sync.Mutex
+	clock clock.Clock
+	otps  []model.OTP
+}
+
+func NewOTPRepositoryMemory(clock clock.Clock) OTPRepository {
+	return &OTPRepositoryMemory{
+		clock: clock,
+	}
+}
+
+func (r *OTPRepositoryMemory) Save(ctx context.Context, otp *model.OTP) (*model.OTP, error) {
+	otp.Email = canonicalEmail(otp.Email)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if otp.ID.IsZero() {
+		otp.ID = primitive.NewObjectID()
+		otp.CreatedAt = r.clock.Now()
+		r.otps = append(r.otps, *otp)
+		return otp, nil
+	}
+	for i := range r.otps {
+		if r.otps[i].ID == otp.ID {
+			r.otps[i] = *otp
+		}
+	}
+	return otp, nil
+}
+
+// Supersede stores otp as the single active code for its email and purpose,
+// replacing any earlier unused code.
+func (r *OTPRepositoryMemory) Supersede(ctx context.Context, otp *model.OTP) (*model.OTP, error) {
+	otp.Email = canonicalEmail(otp.Email)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteWhere(func(existing *model.OTP) bool {
+		return existing.Email == otp.Email && existing.Purpose == otp.Purpose && !existing.Used
+	})
+	otp.ID = primitive.NewObjectID()
+	otp.CreatedAt = r.clock.Now()
+	r.otps = append(r.otps, *otp)
+	return otp, nil
+}
+
+func (r *OTPRepositoryMemory) FindActiveByEmailAndPurpose(ctx context.Context, email, purpose string) (*model.OTP, error) {
+	now := r.clock.Now()
+	return r.latest(func(otp *model.OTP) bool {
+		return otp.Email == canonicalEmail(email) && otp.Purpose == purpose && !otp.Used && otp.ExpiresAt.After(now)
+	}), nil
+}
+
+func (r *OTPRepositoryMemory) FindLatestByEmailAndPurpose(ctx context.Context, email, purpose string) (*model.OTP, error) {
+	return r.latest(func(otp *model.OTP) bool {
+		return otp.Email == canonicalEmail(email) && otp.Purpose == purpose
+	}), nil
+}
+
+func (r *OTPRepositoryMemory) MarkAsUsed(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.otps {
+		if r.otps[i].ID == id {
+			r.otps[i].Used = true
+		}
+	}
+	return nil
+}
+
+func (r *OTPRepositoryMemory) ClaimAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.otps {
+		if r.otps[i].ID == id && !r.otps[i].Used && r.otps[i].Attempts < maxAttempts {
+			r.otps[i].Attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *OTPRepositoryMemory) DeleteExpired(ctx context.Context) (int64, error) {
+	now := r.clock.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deleteWhere(func(otp *model.OTP) bool {
+		return otp.ExpiresAt.Before(now)
+	}), nil
+}
+
+func (r *OTPRepositoryMemory) DeleteByEmail(ctx context.Context, email string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deleteWhere(func(otp *model.OTP) bool {
+		return otp.Email == canonicalEmail(email)
+	}), nil
+}
+
+// MigrateLegacyCodes has nothing to migrate: codes are only ever stored
+// hashed in memory.
+func (r *OTPRepositoryMemory) MigrateLegacyCodes(ctx context.Context, hash func(code string) string) (int64, error) {
+	return 0, nil
+}
+
+// latest returns a copy of the most recently created matching code.
+func (r *OTPRepositoryMemory) latest(match func(*model.OTP) bool) *model.OTP {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.otps) - 1; i >= 0; i-- {
+		if match(&r.otps[i]) {
+			otp := r.otps[i]
+			return &otp
+		}
+	}
+	return nil
+}
+
+// deleteWhere removes matching codes and returns how many it removed. The
+// caller holds the lock.
+func (r *OTPRepositoryMemory) deleteWhere(match func(*model.OTP) bool) int64 {
+	kept := r.otps[:0]
+	for _, otp := range r.otps {
+		if !match(&otp) {
+			kept = append(kept, otp)
+		}
+	}
+	deleted := int64(len(r.otps) - len(kept))
+	r.otps = kept
+	return deleted
+}
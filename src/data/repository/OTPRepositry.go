@@ -2,6 +2,7 @@ package repository
 
 import (
 	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/metrics"
 	"context"
 	"time"
 
@@ -13,11 +14,16 @@ import (
 
 type OTPRepository interface {
 	Save(ctx context.Context, otp *model.OTP) (*model.OTP, error)
-	FindByEmailAndCode(ctx context.Context, email, code string) (*model.OTP, error)
+	Supersede(ctx context.Context, otp *model.OTP) (*model.OTP, error)
+	FindActiveByEmailAndPurpose(ctx context.Context, email, purpose string) (*model.OTP, error)
 	FindLatestByEmailAndPurpose(ctx context.Context, email, purpose string) (*model.OTP, error)
 	MarkAsUsed(ctx context.Context, id primitive.ObjectID) error
-	DeleteExpired(ctx context.Context) error
-	DeleteByEmail(ctx context.Context, email string) error
+	// ClaimAttempt records one try against an unused code and reports
+	// whether it was within maxAttempts.
+	ClaimAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+	DeleteByEmail(ctx context.Context, email string) (int64, error)
+	MigrateLegacyCodes(ctx context.Context, hash func(code string) string) (int64, error)
 }
 
 type OTPRepositoryImpl struct {
@@ -25,20 +31,14 @@ type OTPRepositoryImpl struct {
 }
 
 func NewOTPRepositoryImpl(database *mongo.Database) OTPRepository {
-	collection := database.Collection("otps")
-	
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "expires_at", Value: 1}},
-		Options: options.Index().SetExpireAfterSeconds(0),
-	}
-	collection.Indexes().CreateOne(context.Background(), indexModel)
-	
 	return &OTPRepositoryImpl{
-		collection: collection,
+		collection: database.Collection("otps"),
 	}
 }
 
 func (r *OTPRepositoryImpl) Save(ctx context.Context, otp *model.OTP) (*model.OTP, error) {
+	defer metrics.TimeMongo("otps", "save")()
+	otp.Email = canonicalEmail(otp.Email)
 	if otp.ID.IsZero() {
 		otp.CreatedAt = time.Now()
 		result, err := r.collection.InsertOne(ctx, otp)
@@ -56,16 +56,49 @@ func (r *OTPRepositoryImpl) Save(ctx context.Context, otp *model.OTP) (*model.OT
 	return otp, nil
 }
 
-func (r *OTPRepositoryImpl) FindByEmailAndCode(ctx context.Context, email, code string) (*model.OTP, error) {
+// Supersede stores otp as the single active code for its email and purpose,
+// atomically replacing any earlier unused code.
+func (r *OTPRepositoryImpl) Supersede(ctx context.Context, otp *model.OTP) (*model.OTP, error) {
+	defer metrics.TimeMongo("otps", "supersede")()
+	otp.Email = canonicalEmail(otp.Email)
+	otp.ID = primitive.NilObjectID
+	otp.CreatedAt = time.Now()
+
+	filter := bson.M{
+		"email":   otp.Email,
+		"purpose": otp.Purpose,
+		"used":    false,
+	}
+	opts := options.FindOneAndReplace().
+		SetUpsert(true).
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"_id": 1})
+
+	var saved model.OTP
+	err := r.collection.FindOneAndReplace(ctx, filter, otp, opts).Decode(&saved)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request inserted the active code first; replace it.
+		err = r.collection.FindOneAndReplace(ctx, filter, otp, opts).Decode(&saved)
+	}
+	if err != nil {
+		return nil, err
+	}
+	otp.ID = saved.ID
+	return otp, nil
+}
+
+func (r *OTPRepositoryImpl) FindActiveByEmailAndPurpose(ctx context.Context, email, purpose string) (*model.OTP, error) {
+	defer metrics.TimeMongo("otps", "find_active_by_email_and_purpose")()
 	var otp model.OTP
 	filter := bson.M{
-		"email": email,
-		"code":  code,
-		"used":  false,
+		"email":      canonicalEmail(email),
+		"purpose":    purpose,
+		"used":       false,
 		"expires_at": bson.M{"$gt": time.Now()},
 	}
-	
-	err := r.collection.FindOne(ctx, filter).Decode(&otp)
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&otp)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -76,12 +109,13 @@ func (r *OTPRepositoryImpl) FindByEmailAndCode(ctx context.Context, email, code
 }
 
 func (r *OTPRepositoryImpl) FindLatestByEmailAndPurpose(ctx context.Context, email, purpose string) (*model.OTP, error) {
+	defer metrics.TimeMongo("otps", "find_latest_by_email_and_purpose")()
 	var otp model.OTP
 	filter := bson.M{
-		"email":   email,
+		"email":   canonicalEmail(email),
 		"purpose": purpose,
 	}
-	
+
 	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
 	err := r.collection.FindOne(ctx, filter, opts).Decode(&otp)
 	if err != nil {
@@ -94,20 +128,79 @@ func (r *OTPRepositoryImpl) FindLatestByEmailAndPurpose(ctx context.Context, ema
 }
 
 func (r *OTPRepositoryImpl) MarkAsUsed(ctx context.Context, id primitive.ObjectID) error {
+	defer metrics.TimeMongo("otps", "mark_as_used")()
 	filter := bson.M{"_id": id}
 	update := bson.M{"$set": bson.M{"used": true}}
 	_, err := r.collection.UpdateOne(ctx, filter, update)
 	return err
 }
 
-func (r *OTPRepositoryImpl) DeleteExpired(ctx context.Context) error {
+func (r *OTPRepositoryImpl) ClaimAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) (bool, error) {
+	defer metrics.TimeMongo("otps", "claim_attempt")()
+	// $not also matches codes issued before attempts were counted.
+	filter := bson.M{
+		"_id":      id,
+		"used":     false,
+		"attempts": bson.M{"$not": bson.M{"$gte": maxAttempts}},
+	}
+	update := bson.M{"$inc": bson.M{"attempts": 1}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *OTPRepositoryImpl) DeleteExpired(ctx context.Context) (int64, error) {
+	defer metrics.TimeMongo("otps", "delete_expired")()
 	filter := bson.M{"expires_at": bson.M{"$lt": time.Now()}}
-	_, err := r.collection.DeleteMany(ctx, filter)
-	return err
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
 }
 
-func (r *OTPRepositoryImpl) DeleteByEmail(ctx context.Context, email string) error {
-	filter := bson.M{"email": email}
-	_, err := r.collection.DeleteMany(ctx, filter)
-	return err
-}
\ No newline at end of file
+func (r *OTPRepositoryImpl) DeleteByEmail(ctx context.Context, email string) (int64, error) {
+	defer metrics.TimeMongo("otps", "delete_by_email")()
+	filter := bson.M{"email": canonicalEmail(email)}
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// MigrateLegacyCodes rewrites documents created before codes were hashed,
+// replacing the plaintext "code" field with its hash. It returns the number
+// of documents migrated.
+func (r *OTPRepositoryImpl) MigrateLegacyCodes(ctx context.Context, hash func(code string) string) (int64, error) {
+	defer metrics.TimeMongo("otps", "migrate_legacy_codes")()
+	filter := bson.M{"code": bson.M{"$exists": true}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var migrated int64
+	for cursor.Next(ctx) {
+		var legacy struct {
+			ID   primitive.ObjectID `bson:"_id"`
+			Code string             `bson:"code"`
+		}
+		if err := cursor.Decode(&legacy); err != nil {
+			return migrated, err
+		}
+
+		update := bson.M{
+			"$set":   bson.M{"code_hash": hash(legacy.Code)},
+			"$unset": bson.M{"code": ""},
+		}
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": legacy.ID}, update); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
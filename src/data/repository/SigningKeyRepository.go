@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/metrics"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SigningKeyRepository interface {
+	Save(ctx context.Context, key *model.SigningKey) error
+	FindUnexpired(ctx context.Context) ([]*model.SigningKey, error)
+}
+
+type SigningKeyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewSigningKeyRepositoryImpl(database *mongo.Database) SigningKeyRepository {
+	return &SigningKeyRepositoryImpl{
+		collection: database.Collection("signing_keys"),
+	}
+}
+
+func (r *SigningKeyRepositoryImpl) Save(ctx context.Context, key *model.SigningKey) error {
+	defer metrics.TimeMongo("signing_keys", "save")()
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (r *SigningKeyRepositoryImpl) FindUnexpired(ctx context.Context) ([]*model.SigningKey, error) {
+	defer metrics.TimeMongo("signing_keys", "find_unexpired")()
+	cursor, err := r.collection.Find(ctx, bson.M{"expires_at": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []*model.SigningKey
+	for cursor.Next(ctx) {
+		var key model.SigningKey
+		if err := cursor.Decode(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -3,21 +3,59 @@ package repository
 import (
     "errors"
     "context"
+    "regexp"
+    "Student-Assistant-App/src/data/enums"
     "Student-Assistant-App/src/data/model"
+    "Student-Assistant-App/src/metrics"
+    "Student-Assistant-App/src/tenant"
+    "Student-Assistant-App/src/utils"
     "go.mongodb.org/mongo-driver/bson"
     "go.mongodb.org/mongo-driver/bson/primitive"
     "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrDuplicateEmail is returned by Save when another user already has the
+// email, as enforced by the unique index on users.email.
+var ErrDuplicateEmail = errors.New("user already exists with this email")
+
+// ErrWrongInstitution is returned by Save when the user belongs to an
+// institution other than the one the context is scoped to.
+var ErrWrongInstitution = errors.New("user belongs to another institution")
+
+// EmailCollation makes email comparisons case-insensitive. Queries on email
+// must use it so they match, and can use, the unique email index.
+var EmailCollation = &options.Collation{Locale: "en", Strength: 2}
+
 type UserRepository interface {
     Save(ctx context.Context, user *model.User) (*model.User, error)
     FindByID(ctx context.Context, id string) (*model.User, error)
     FindByEmail(ctx context.Context, email string) (*model.User, error)
     FindAll(ctx context.Context) ([]*model.User, error)
+    FindByFilter(ctx context.Context, filter UserFilter) ([]*model.User, error)
     DeleteByID(ctx context.Context, id string) error
     ExistsByEmail(ctx context.Context, email string) (bool, error)
+    FindDuplicateEmails(ctx context.Context) ([]DuplicateEmail, error)
+    CountByInstitution(ctx context.Context, institutionID string) (int64, error)
+}
+
+// DuplicateEmail is a set of accounts that share an email, ignoring case,
+// and need an administrator to merge or remove them.
+type DuplicateEmail struct {
+    Email string        `json:"email"`
+    Users []*model.User `json:"users"`
 }
 
+// UserFilter narrows a user listing. Zero fields match everything.
+type UserFilter struct {
+    Role          enums.Role
+    InstitutionID string
+    // Search matches a substring of the name or email, ignoring case.
+    Search string
+}
+
+// UserRepositoryImpl scopes every query to the institution in the context,
+// if any, so tenants cannot read or modify each other's users.
 type UserRepositoryImpl struct {
     collection *mongo.Collection
 }
@@ -29,15 +67,31 @@ func NewUserRepositoryImpl(database *mongo.Database) UserRepository {
 }
 
 func (r *UserRepositoryImpl) Save(ctx context.Context, user *model.User) (*model.User, error) {
+    defer metrics.TimeMongo("users", "save")()
+    user.Email = canonicalEmail(user.Email)
+    if institutionID, ok := tenant.InstitutionFromContext(ctx); ok {
+        if user.InstitutionID == "" {
+            user.InstitutionID = institutionID
+        }
+        if user.InstitutionID != institutionID {
+            return nil, ErrWrongInstitution
+        }
+    }
     if user.ID.IsZero() {
         result, err := r.collection.InsertOne(ctx, user)
+        if mongo.IsDuplicateKeyError(err) {
+            return nil, ErrDuplicateEmail
+        }
         if err != nil {
             return nil, err
         }
         user.ID = result.InsertedID.(primitive.ObjectID)
     } else {
-        filter := bson.M{"_id": user.ID}
+        filter := scopeFilter(ctx, bson.M{"_id": user.ID})
         _, err := r.collection.ReplaceOne(ctx, filter, user)
+        if mongo.IsDuplicateKeyError(err) {
+            return nil, ErrDuplicateEmail
+        }
         if err != nil {
             return nil, err
         }
@@ -46,12 +100,13 @@ func (r *UserRepositoryImpl) Save(ctx context.Context, user *model.User) (*model
 }
 
 func (r *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*model.User, error) {
+    defer metrics.TimeMongo("users", "find_by_id")()
     objectId, err := primitive.ObjectIDFromHex(id)
     if err != nil {
         return nil, err
     }
     var user model.User
-    err = r.collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&user)
+    err = r.collection.FindOne(ctx, scopeFilter(ctx, bson.M{"_id": objectId})).Decode(&user)
     if err != nil {
         if errors.Is(err, mongo.ErrNoDocuments) {
             return nil, nil
@@ -62,8 +117,10 @@ func (r *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*model.Us
 }
 
 func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+    defer metrics.TimeMongo("users", "find_by_email")()
     var user model.User
-    err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+    opts := options.FindOne().SetCollation(EmailCollation)
+    err := r.collection.FindOne(ctx, scopeFilter(ctx, bson.M{"email": canonicalEmail(email)}), opts).Decode(&user)
     if err != nil {
         if errors.Is(err, mongo.ErrNoDocuments) {
             return nil, nil
@@ -74,7 +131,31 @@ func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*mo
 }
 
 func (r *UserRepositoryImpl) FindAll(ctx context.Context) ([]*model.User, error) {
-    cursor, err := r.collection.Find(ctx, bson.M{})
+    defer metrics.TimeMongo("users", "find_all")()
+    return r.find(ctx, bson.M{}, options.Find())
+}
+
+func (r *UserRepositoryImpl) FindByFilter(ctx context.Context, filter UserFilter) ([]*model.User, error) {
+    defer metrics.TimeMongo("users", "find_by_filter")()
+    query := bson.M{}
+    if filter.Role != "" {
+        query["role"] = filter.Role
+    }
+    if filter.InstitutionID != "" {
+        query["institution_id"] = filter.InstitutionID
+    }
+    if filter.Search != "" {
+        pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+        query["$or"] = bson.A{
+            bson.M{"name": pattern},
+            bson.M{"email": pattern},
+        }
+    }
+    return r.find(ctx, query, options.Find().SetSort(bson.D{{Key: "email", Value: 1}}))
+}
+
+func (r *UserRepositoryImpl) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*model.User, error) {
+    cursor, err := r.collection.Find(ctx, scopeFilter(ctx, filter), opts)
     if err != nil {
         return nil, err
     }
@@ -95,18 +176,77 @@ func (r *UserRepositoryImpl) FindAll(ctx context.Context) ([]*model.User, error)
 }
 
 func (r *UserRepositoryImpl) DeleteByID(ctx context.Context, id string) error {
+    defer metrics.TimeMongo("users", "delete_by_id")()
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
         return err
     }
-    _, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+    _, err = r.collection.DeleteOne(ctx, scopeFilter(ctx, bson.M{"_id": objectID}))
     return err
 }
 
 func (r *UserRepositoryImpl) ExistsByEmail(ctx context.Context, email string) (bool, error) {
-    count, err := r.collection.CountDocuments(ctx, bson.M{"email": email})
+    defer metrics.TimeMongo("users", "exists_by_email")()
+    opts := options.Count().SetCollation(EmailCollation)
+    count, err := r.collection.CountDocuments(ctx, scopeFilter(ctx, bson.M{"email": canonicalEmail(email)}), opts)
     if err != nil {
         return false, err
     }
     return count > 0, nil
 }
+
+// FindDuplicateEmails groups users whose emails match ignoring case and
+// surrounding space. Migration 4 rewrites other variants, such as Unicode
+// domains, into canonical form, after which they are exact duplicates.
+func (r *UserRepositoryImpl) FindDuplicateEmails(ctx context.Context) ([]DuplicateEmail, error) {
+    defer metrics.TimeMongo("users", "find_duplicate_emails")()
+    pipeline := mongo.Pipeline{
+        {{Key: "$match", Value: scopeFilter(ctx, bson.M{})}},
+        {{Key: "$group", Value: bson.M{
+            "_id":   bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}},
+            "users": bson.M{"$push": "$$ROOT"},
+            "count": bson.M{"$sum": 1},
+        }}},
+        {{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+        {{Key: "$sort", Value: bson.M{"_id": 1}}},
+    }
+    cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var duplicates []DuplicateEmail
+    for cursor.Next(ctx) {
+        var group struct {
+            Email string        `bson:"_id"`
+            Users []*model.User `bson:"users"`
+        }
+        if err := cursor.Decode(&group); err != nil {
+            return nil, err
+        }
+        duplicates = append(duplicates, DuplicateEmail{Email: group.Email, Users: group.Users})
+    }
+    return duplicates, cursor.Err()
+}
+
+func (r *UserRepositoryImpl) CountByInstitution(ctx context.Context, institutionID string) (int64, error) {
+    defer metrics.TimeMongo("users", "count_by_institution")()
+    return r.collection.CountDocuments(ctx, bson.M{"institution_id": institutionID})
+}
+
+// canonicalEmail normalises an email for storage and lookup. Addresses that
+// fail validation are still trimmed and lower-cased so lookups stay
+// consistent with what was stored.
+func canonicalEmail(email string) string {
+    normalized, _ := utils.NormalizeEmail(email)
+    return normalized
+}
+
+// scopeFilter restricts filter to the institution ctx is scoped to.
+func scopeFilter(ctx context.Context, filter bson.M) bson.M {
+    if institutionID, ok := tenant.InstitutionFromContext(ctx); ok {
+        filter["institution_id"] = institutionID
+    }
+    return filter
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/tenant"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserRepositoryMemory is an in-memory UserRepository for tests and local
+// tooling. Like the Mongo implementation it keeps emails unique, ignoring
+// case, and scopes every query to the institution in the context. It is
+// safe for concurrent use.
+type UserRepositoryMemory struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]model.User
+}
+
+func NewUserRepositoryMemory() UserRepository {
+	return &UserRepositoryMemory{
+		users: make(map[primitive.ObjectID]model.User),
+	}
+}
+
+func (r *UserRepositoryMemory) Save(ctx context.Context, user *model.User) (*model.User, error) {
+	user.Email = canonicalEmail(user.Email)
+	if institutionID, ok := tenant.InstitutionFromContext(ctx); ok {
+		if user.InstitutionID == "" {
+			user.InstitutionID = institutionID
+		}
+		if user.InstitutionID != institutionID {
+			return nil, ErrWrongInstitution
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, existing := range r.users {
+		if id != user.ID && existing.Email == user.Email {
+			return nil, ErrDuplicateEmail
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	} else if existing, ok := r.users[user.ID]; !ok || !inScope(ctx, &existing) {
+		// Like ReplaceOne, updating a user that is not there is a no-op.
+		return user, nil
+	}
+	r.users[user.ID] = *user
+	return user, nil
+}
+
+func (r *UserRepositoryMemory) FindByID(ctx context.Context, id string) (*model.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[objectID]
+	if !ok || !inScope(ctx, &user) {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (r *UserRepositoryMemory) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	users := r.find(ctx, func(user *model.User) bool {
+		return user.Email == canonicalEmail(email)
+	})
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return users[0], nil
+}
+
+func (r *UserRepositoryMemory) FindAll(ctx context.Context) ([]*model.User, error) {
+	return r.find(ctx, func(*model.User) bool { return true }), nil
+}
+
+func (r *UserRepositoryMemory) FindByFilter(ctx context.Context, filter UserFilter) ([]*model.User, error) {
+	search := strings.ToLower(filter.Search)
+	users := r.find(ctx, func(user *model.User) bool {
+		return (filter.Role == "" || user.Role == filter.Role) &&
+			(filter.InstitutionID == "" || user.InstitutionID == filter.InstitutionID) &&
+			(search == "" || strings.Contains(strings.ToLower(user.Name), search) || strings.Contains(user.Email, search))
+	})
+	slices.SortStableFunc(users, func(a, b *model.User) int {
+		return strings.Compare(a.Email, b.Email)
+	})
+	return users, nil
+}
+
+func (r *UserRepositoryMemory) DeleteByID(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[objectID]; ok && inScope(ctx, &user) {
+		delete(r.users, objectID)
+	}
+	return nil
+}
+
+func (r *UserRepositoryMemory) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	user, err := r.FindByEmail(ctx, email)
+	return user != nil, err
+}
+
+// FindDuplicateEmails finds nothing, since Save keeps emails unique.
+// Duplicates only arise from data written before emails were normalised.
+func (r *UserRepositoryMemory) FindDuplicateEmails(ctx context.Context) ([]DuplicateEmail, error) {
+	return nil, nil
+}
+
+func (r *UserRepositoryMemory) CountByInstitution(ctx context.Context, institutionID string) (int64, error) {
+	users := r.find(context.Background(), func(user *model.User) bool {
+		return user.InstitutionID == institutionID
+	})
+	return int64(len(users)), nil
+}
+
+// find returns copies of the users in scope that match, ordered by ID so
+// results are deterministic.
+func (r *UserRepositoryMemory) find(ctx context.Context, match func(*model.User) bool) []*model.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []*model.User
+	for _, user := range r.users {
+		if inScope(ctx, &user) && match(&user) {
+			users = append(users, &user)
+		}
+	}
+	slices.SortFunc(users, func(a, b *model.User) int {
+		return strings.Compare(a.ID.Hex(), b.ID.Hex())
+	})
+	return users
+}
+
+// inScope reports whether user belongs to the institution ctx is scoped to,
+// if any.
+func inScope(ctx context.Context, user *model.User) bool {
+	institutionID, ok := tenant.InstitutionFromContext(ctx)
+	return !ok || user.InstitutionID == institutionID
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/tenant"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func saveUsers(t *testing.T, repo UserRepository, users ...model.User) []*model.User {
+	t.Helper()
+	var saved []*model.User
+	for _, user := range users {
+		stored, err := repo.Save(context.Background(), &user)
+		if err != nil {
+			t.Fatalf("Save %s: %v", user.Email, err)
+		}
+		saved = append(saved, stored)
+	}
+	return saved
+}
+
+func TestUserRepositoryMemoryDuplicateEmail(t *testing.T) {
+	repo := NewUserRepositoryMemory()
+	users := saveUsers(t, repo,
+		model.User{Name: "Ada", Email: "Ada@Example.com"},
+		model.User{Name: "Grace", Email: "grace@example.com"},
+	)
+	if users[0].Email != "ada@example.com" {
+		t.Errorf("email = %q, want it lower-cased", users[0].Email)
+	}
+
+	if _, err := repo.Save(context.Background(), &model.User{Email: "ADA@example.com"}); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("Save duplicate error = %v, want %v", err, ErrDuplicateEmail)
+	}
+	users[1].Email = "ada@example.com"
+	if _, err := repo.Save(context.Background(), users[1]); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("update to a taken email error = %v, want %v", err, ErrDuplicateEmail)
+	}
+
+	users[0].Name = "Ada Lovelace"
+	if _, err := repo.Save(context.Background(), users[0]); err != nil {
+		t.Fatalf("Save with an unchanged email: %v", err)
+	}
+	found, _ := repo.FindByEmail(context.Background(), "ADA@EXAMPLE.COM")
+	if found == nil || found.Name != "Ada Lovelace" {
+		t.Errorf("FindByEmail = %+v, want the renamed user", found)
+	}
+}
+
+func TestUserRepositoryMemoryTenantScope(t *testing.T) {
+	repo := NewUserRepositoryMemory()
+	users := saveUsers(t, repo,
+		model.User{Email: "a1@uni-a.edu", InstitutionID: "uni-a"},
+		model.User{Email: "a2@uni-a.edu", InstitutionID: "uni-a"},
+		model.User{Email: "b1@uni-b.edu", InstitutionID: "uni-b"},
+	)
+	uniA := tenant.WithInstitution(context.Background(), "uni-a")
+	outsider := users[2]
+
+	all, _ := repo.FindAll(uniA)
+	if len(all) != 2 {
+		t.Errorf("FindAll in uni-a = %d users, want 2", len(all))
+	}
+	if user, _ := repo.FindByID(uniA, outsider.ID.Hex()); user != nil {
+		t.Error("FindByID returned a user from another institution")
+	}
+	if exists, _ := repo.ExistsByEmail(uniA, outsider.Email); exists {
+		t.Error("ExistsByEmail saw a user from another institution")
+	}
+
+	outsider.Name = "hijacked"
+	if _, err := repo.Save(uniA, outsider); !errors.Is(err, ErrWrongInstitution) {
+		t.Errorf("Save across institutions error = %v, want %v", err, ErrWrongInstitution)
+	}
+	if err := repo.DeleteByID(uniA, outsider.ID.Hex()); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+	if user, _ := repo.FindByID(context.Background(), outsider.ID.Hex()); user == nil || user.Name == "hijacked" {
+		t.Errorf("user from another institution was modified: %+v", user)
+	}
+
+	created, err := repo.Save(uniA, &model.User{Email: "a3@uni-a.edu"})
+	if err != nil || created.InstitutionID != "uni-a" {
+		t.Errorf("Save in uni-a = %+v, %v; want it assigned to uni-a", created, err)
+	}
+	if count, _ := repo.CountByInstitution(uniA, "uni-b"); count != 1 {
+		t.Errorf("CountByInstitution(uni-b) = %d, want 1", count)
+	}
+}
+
+func TestUserRepositoryMemoryFindByFilter(t *testing.T) {
+	repo := NewUserRepositoryMemory()
+	saveUsers(t, repo,
+		model.User{Name: "Zoe Admin", Email: "zoe@uni-a.edu", Role: enums.Admin, InstitutionID: "uni-a"},
+		model.User{Name: "Ada", Email: "ada@uni-a.edu", Role: enums.User, InstitutionID: "uni-a"},
+		model.User{Name: "Bea", Email: "bea@uni-b.edu", Role: enums.User, InstitutionID: "uni-b"},
+		model.User{Name: "Cleo", Email: "cleo@example.com", Role: enums.Guest},
+	)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		filter UserFilter
+		want   []string
+	}{
+		{"everyone, by email", context.Background(), UserFilter{}, []string{"ada@uni-a.edu", "bea@uni-b.edu", "cleo@example.com", "zoe@uni-a.edu"}},
+		{"role", context.Background(), UserFilter{Role: enums.User}, []string{"ada@uni-a.edu", "bea@uni-b.edu"}},
+		{"institution", context.Background(), UserFilter{InstitutionID: "uni-a"}, []string{"ada@uni-a.edu", "zoe@uni-a.edu"}},
+		{"search name ignoring case", context.Background(), UserFilter{Search: "ADMIN"}, []string{"zoe@uni-a.edu"}},
+		{"search email", context.Background(), UserFilter{Search: "example"}, []string{"cleo@example.com"}},
+		{"combined", context.Background(), UserFilter{Role: enums.User, Search: "uni-b"}, []string{"bea@uni-b.edu"}},
+		{"scoped context", tenant.WithInstitution(context.Background(), "uni-b"), UserFilter{Role: enums.User}, []string{"bea@uni-b.edu"}},
+		{"no match", context.Background(), UserFilter{Role: enums.InstitutionAdmin}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users, err := repo.FindByFilter(test.ctx, test.filter)
+			if err != nil {
+				t.Fatalf("FindByFilter: %v", err)
+			}
+			var emails []string
+			for _, user := range users {
+				emails = append(emails, user.Email)
+			}
+			if !slices.Equal(emails, test.want) {
+				t.Errorf("FindByFilter = %v, want %v", emails, test.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,17 @@ package request
 
 import (
 	"Student-Assistant-App/src/data/enums"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// CreateUserRequest carries no role: signups always get the configured
+// default role, and roles are only changed through the admin role endpoint.
 type CreateUserRequest struct {
-	Name     string     `bson:"name"     json:"name"`
-	Email    string     `bson:"email"    json:"email"`
-	Password string     `bson:"password" json:"password"`
-	Role     enums.Role `bson:"role"     json:"role"`
+	Name     string `bson:"name"     json:"name"`
+	Email    string `bson:"email"    json:"email"`
+	Password string `bson:"password" json:"password"`
 }
 
 func (req *CreateUserRequest) SetName(Name string) {
@@ -30,12 +33,6 @@ func (req *CreateUserRequest) SetPassword(Password string) {
 func (req *CreateUserRequest) GetPassword() string {
 	return req.Password
 }
-func (req *CreateUserRequest) SetRole(Role enums.Role) {
-	req.Role = Role
-}
-func (req *CreateUserRequest) GetRole() enums.Role {
-	return req.Role
-}
 
 type LoginRequest struct {
 	Email    string `json:"email"`
@@ -129,9 +126,8 @@ func (req *LoginWithOTPRequest) GetOTPCode() string {
 }
 
 type UpdateUserRequest struct {
-	Name  string     `json:"name"`
-	Email string     `json:"email"`
-	Role  enums.Role `json:"role"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
 }
 
 func (req *UpdateUserRequest) SetName(name string) {
@@ -146,12 +142,6 @@ func (req *UpdateUserRequest) SetEmail(email string) {
 func (req *UpdateUserRequest) GetEmail() string {
 	return req.Email
 }
-func (req *UpdateUserRequest) SetRole(role enums.Role) {
-	req.Role = role
-}
-func (req *UpdateUserRequest) GetRole() enums.Role {
-	return req.Role
-}
 
 type DeleteUserRequest struct {
 	Id primitive.ObjectID `json:"id" bson:"_id"`
@@ -162,4 +152,207 @@ func (req *DeleteUserRequest) SetId(ID primitive.ObjectID) {
 }
 func (req *DeleteUserRequest) GetId() primitive.ObjectID {
 	return req.Id
-}
\ No newline at end of file
+}
+
+type CreateInstitutionRequest struct {
+	ID      string   `json:"id" binding:"required"`
+	Name    string   `json:"name" binding:"required"`
+	Domains []string `json:"domains" binding:"required"`
+}
+
+func (req *CreateInstitutionRequest) SetID(id string) {
+	req.ID = id
+}
+func (req *CreateInstitutionRequest) GetID() string {
+	return req.ID
+}
+func (req *CreateInstitutionRequest) SetName(name string) {
+	req.Name = name
+}
+func (req *CreateInstitutionRequest) GetName() string {
+	return req.Name
+}
+func (req *CreateInstitutionRequest) SetDomains(domains []string) {
+	req.Domains = domains
+}
+func (req *CreateInstitutionRequest) GetDomains() []string {
+	return req.Domains
+}
+
+type UpdateInstitutionRequest struct {
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+}
+
+func (req *UpdateInstitutionRequest) SetName(name string) {
+	req.Name = name
+}
+func (req *UpdateInstitutionRequest) GetName() string {
+	return req.Name
+}
+func (req *UpdateInstitutionRequest) SetDomains(domains []string) {
+	req.Domains = domains
+}
+func (req *UpdateInstitutionRequest) GetDomains() []string {
+	return req.Domains
+}
+
+// ImportUserRow is one user in a bulk import. Password is optional; users
+// imported without one set it through an invitation code.
+type ImportUserRow struct {
+	Name     string     `json:"name"`
+	Email    string     `json:"email"`
+	Role     enums.Role `json:"role"`
+	Password string     `json:"password"`
+}
+
+func (req *ImportUserRow) SetName(name string) {
+	req.Name = name
+}
+func (req *ImportUserRow) GetName() string {
+	return req.Name
+}
+func (req *ImportUserRow) SetEmail(email string) {
+	req.Email = email
+}
+func (req *ImportUserRow) GetEmail() string {
+	return req.Email
+}
+func (req *ImportUserRow) SetRole(role enums.Role) {
+	req.Role = role
+}
+func (req *ImportUserRow) GetRole() enums.Role {
+	return req.Role
+}
+func (req *ImportUserRow) SetPassword(password string) {
+	req.Password = password
+}
+func (req *ImportUserRow) GetPassword() string {
+	return req.Password
+}
+
+type SetPasswordRequest struct {
+	Email    string `json:"email" binding:"required"`
+	OTPCode  string `json:"otp_code" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// Purpose is "set_password" (the default) for invited users or
+	// "password_reset" after a reset code was requested.
+	Purpose string `json:"purpose"`
+}
+
+func (req *SetPasswordRequest) SetEmail(email string) {
+	req.Email = email
+}
+func (req *SetPasswordRequest) GetEmail() string {
+	return req.Email
+}
+func (req *SetPasswordRequest) SetOTPCode(code string) {
+	req.OTPCode = code
+}
+func (req *SetPasswordRequest) GetOTPCode() string {
+	return req.OTPCode
+}
+func (req *SetPasswordRequest) SetPassword(password string) {
+	req.Password = password
+}
+func (req *SetPasswordRequest) GetPassword() string {
+	return req.Password
+}
+func (req *SetPasswordRequest) SetPurpose(purpose string) {
+	req.Purpose = purpose
+}
+func (req *SetPasswordRequest) GetPurpose() string {
+	return req.Purpose
+}
+
+type CreateInvitationRequest struct {
+	Email string     `json:"email" binding:"required"`
+	Role  enums.Role `json:"role"`
+	// InstitutionID is honoured for global admins only; institution admins
+	// always invite into their own institution.
+	InstitutionID string `json:"institution_id"`
+}
+
+func (req *CreateInvitationRequest) SetEmail(email string) {
+	req.Email = email
+}
+func (req *CreateInvitationRequest) GetEmail() string {
+	return req.Email
+}
+func (req *CreateInvitationRequest) SetRole(role enums.Role) {
+	req.Role = role
+}
+func (req *CreateInvitationRequest) GetRole() enums.Role {
+	return req.Role
+}
+func (req *CreateInvitationRequest) SetInstitutionID(institutionID string) {
+	req.InstitutionID = institutionID
+}
+func (req *CreateInvitationRequest) GetInstitutionID() string {
+	return req.InstitutionID
+}
+
+// AcceptInvitationRequest deliberately has no role: the invitation decides
+// it.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func (req *AcceptInvitationRequest) SetToken(token string) {
+	req.Token = token
+}
+func (req *AcceptInvitationRequest) GetToken() string {
+	return req.Token
+}
+func (req *AcceptInvitationRequest) SetName(name string) {
+	req.Name = name
+}
+func (req *AcceptInvitationRequest) GetName() string {
+	return req.Name
+}
+func (req *AcceptInvitationRequest) SetPassword(password string) {
+	req.Password = password
+}
+func (req *AcceptInvitationRequest) GetPassword() string {
+	return req.Password
+}
+
+type SetRoleRequest struct {
+	Role enums.Role `json:"role" binding:"required"`
+}
+
+func (req *SetRoleRequest) SetRole(role enums.Role) {
+	req.Role = role
+}
+func (req *SetRoleRequest) GetRole() enums.Role {
+	return req.Role
+}
+
+// CreateAPIKeyRequest defaults to read-only scope and the configured
+// lifetime when Scopes or ExpiresAt are omitted.
+type CreateAPIKeyRequest struct {
+	Name      string        `json:"name" binding:"required"`
+	Scopes    []enums.Scope `json:"scopes"`
+	ExpiresAt *time.Time    `json:"expires_at"`
+}
+
+func (req *CreateAPIKeyRequest) SetName(name string) {
+	req.Name = name
+}
+func (req *CreateAPIKeyRequest) GetName() string {
+	return req.Name
+}
+func (req *CreateAPIKeyRequest) SetScopes(scopes []enums.Scope) {
+	req.Scopes = scopes
+}
+func (req *CreateAPIKeyRequest) GetScopes() []enums.Scope {
+	return req.Scopes
+}
+func (req *CreateAPIKeyRequest) SetExpiresAt(expiresAt *time.Time) {
+	req.ExpiresAt = expiresAt
+}
+func (req *CreateAPIKeyRequest) GetExpiresAt() *time.Time {
+	return req.ExpiresAt
+}
@@ -7,10 +7,13 @@ type Response[T any] struct {
 	Success bool `json:"success"`
 }
 
+// CreateUserResponse and LoginResponse carry either the token or, in cookie
+// session mode, the CSRF token to echo on state-changing requests.
 type CreateUserResponse struct {
-	Message string      `json:"message"`
-	User    *model.User `json:"user"`
-	Token   string      `json:"token,omitempty"`
+	Message   string      `json:"message"`
+	User      *model.User `json:"user"`
+	Token     string      `json:"token,omitempty"`
+	CSRFToken string      `json:"csrf_token,omitempty"`
 }
 
 func (req *CreateUserResponse) SetMessage(Message string) {
@@ -31,11 +34,18 @@ func (req *CreateUserResponse) SetToken(token string) {
 func (req *CreateUserResponse) GetToken() string {
 	return req.Token
 }
+func (req *CreateUserResponse) SetCSRFToken(csrfToken string) {
+	req.CSRFToken = csrfToken
+}
+func (req *CreateUserResponse) GetCSRFToken() string {
+	return req.CSRFToken
+}
 
 type LoginResponse struct {
-	Message string      `json:"message"`
-	User    *model.User `json:"user"`
-	Token   string      `json:"token"`
+	Message   string      `json:"message"`
+	User      *model.User `json:"user"`
+	Token     string      `json:"token,omitempty"`
+	CSRFToken string      `json:"csrf_token,omitempty"`
 }
 
 func (r *LoginResponse) SetMessage(message string) {
@@ -56,6 +66,12 @@ func (r *LoginResponse) SetToken(token string) {
 func (r *LoginResponse) GetToken() string {
 	return r.Token
 }
+func (r *LoginResponse) SetCSRFToken(csrfToken string) {
+	r.CSRFToken = csrfToken
+}
+func (r *LoginResponse) GetCSRFToken() string {
+	return r.CSRFToken
+}
 
 type DeleteUserResponse struct {
 	Message string `json:"message"`
@@ -67,3 +83,57 @@ func (r *DeleteUserResponse) SetMessage(message string) {
 func (r *DeleteUserResponse) GetMessage() string {
 	return r.Message
 }
+
+// ImportUsersResponse summarises a bulk import. In a dry run the counts are
+// what the import would do; nothing is written.
+type ImportUsersResponse struct {
+	Message string             `json:"message"`
+	DryRun  bool               `json:"dry_run"`
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Failed  int                `json:"failed"`
+	Rows    []ImportUserResult `json:"rows"`
+}
+
+func (r *ImportUsersResponse) SetMessage(message string) {
+	r.Message = message
+}
+func (r *ImportUsersResponse) GetMessage() string {
+	return r.Message
+}
+
+// ImportUserResult reports the outcome for one input row, numbered from 1.
+// Invited means a set-password email was queued for the created user.
+type ImportUserResult struct {
+	Row     int    `json:"row"`
+	Email   string `json:"email"`
+	Action  string `json:"action"`
+	Error   string `json:"error,omitempty"`
+	Invited bool   `json:"invited,omitempty"`
+}
+
+// CreateAPIKeyResponse is the only time the full key is returned.
+type CreateAPIKeyResponse struct {
+	Message string        `json:"message"`
+	APIKey  *model.APIKey `json:"api_key"`
+	Key     string        `json:"key"`
+}
+
+func (r *CreateAPIKeyResponse) SetMessage(message string) {
+	r.Message = message
+}
+func (r *CreateAPIKeyResponse) GetMessage() string {
+	return r.Message
+}
+func (r *CreateAPIKeyResponse) SetAPIKey(apiKey *model.APIKey) {
+	r.APIKey = apiKey
+}
+func (r *CreateAPIKeyResponse) GetAPIKey() *model.APIKey {
+	return r.APIKey
+}
+func (r *CreateAPIKeyResponse) SetKey(key string) {
+	r.Key = key
+}
+func (r *CreateAPIKeyResponse) GetKey() string {
+	return r.Key
+}
@@ -0,0 +1,345 @@
+package keys
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/model"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// unknownKeyReloadInterval limits how often a token with an unknown kid can
+// force a reload, so forged tokens cannot hammer the database.
+const unknownKeyReloadInterval = 10 * time.Second
+
+// Repository persists signing keys so every instance shares them.
+type Repository interface {
+	Save(ctx context.Context, key *model.SigningKey) error
+	FindUnexpired(ctx context.Context) ([]*model.SigningKey, error)
+}
+
+// Key is a parsed signing key.
+type Key struct {
+	ID          string
+	Algorithm   string
+	Private     crypto.Signer
+	Public      crypto.PublicKey
+	ActivatesAt time.Time
+	ExpiresAt   time.Time
+}
+
+// Store holds the signing keys shared by all instances and rotates them on
+// schedule. A new key is published for one refresh interval before it
+// starts signing, so every instance and JWKS consumer knows it by the time
+// tokens carry it; retired keys keep verifying until the last token they
+// signed has expired.
+type Store struct {
+	repository Repository
+	config     config.JWTConfig
+	sealer     *sealer
+	logger     *slog.Logger
+
+	mu         sync.RWMutex
+	keys       []*Key
+	lastReload time.Time
+}
+
+func NewStore(repository Repository, jwtConfig config.JWTConfig, logger *slog.Logger) (*Store, error) {
+	sealer, err := newSealer(jwtConfig.KeyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		repository: repository,
+		config:     jwtConfig,
+		sealer:     sealer,
+		logger:     logger,
+	}, nil
+}
+
+// Refresh creates a new key when rotation is due and reloads the key set.
+// It runs at startup and then every KeyRefreshInterval.
+func (s *Store) Refresh(ctx context.Context) error {
+	keys, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var newest *Key
+	if len(keys) > 0 {
+		newest = keys[len(keys)-1]
+	}
+	if newest == nil || !newest.ActivatesAt.After(now.Add(-s.config.RotationInterval)) {
+		activatesAt := now.Add(s.config.KeyRefreshInterval)
+		if activeKey(keys, now) == nil {
+			// Nothing can sign yet, e.g. on first start: activate at once.
+			activatesAt = now
+		}
+		key, err := s.generate(ctx, activatesAt)
+		if err != nil {
+			return err
+		}
+		s.logger.InfoContext(ctx, "signing key created", "kid", key.ID, "alg", key.Algorithm, "activates_at", key.ActivatesAt)
+		keys = append(keys, key)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.lastReload = now
+	s.mu.Unlock()
+	return nil
+}
+
+// Load reads the stored keys without creating or rotating any, for
+// commands that must not change the key set.
+func (s *Store) Load(ctx context.Context) error {
+	keys, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.lastReload = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// SigningKey returns the newest key that has become active.
+func (s *Store) SigningKey() (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key := activeKey(s.keys, time.Now()); key != nil {
+		return key, nil
+	}
+	return nil, ErrNoSigningKey
+}
+
+// VerificationKey returns the unexpired key with the given ID, reloading
+// once if another instance may have created it since the last refresh.
+func (s *Store) VerificationKey(ctx context.Context, kid string) (*Key, error) {
+	if key := s.find(kid); key != nil {
+		return key, nil
+	}
+
+	s.mu.RLock()
+	stale := time.Since(s.lastReload) > unknownKeyReloadInterval
+	s.mu.RUnlock()
+	if stale {
+		keys, err := s.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.keys = keys
+		s.lastReload = time.Now()
+		s.mu.Unlock()
+		if key := s.find(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// JWKS returns the public half of every unexpired key, including keys that
+// are published but not yet signing.
+func (s *Store) JWKS() JSONWebKeySet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.ExpiresAt.After(now) {
+			set.Keys = append(set.Keys, publicJWK(key))
+		}
+	}
+	return set
+}
+
+func (s *Store) find(kid string) *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.ID == kid && key.ExpiresAt.After(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+func (s *Store) load(ctx context.Context) ([]*Key, error) {
+	stored, err := s.repository.FindUnexpired(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(stored))
+	for _, signingKey := range stored {
+		key, err := s.parseKey(signingKey)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "skipping unreadable signing key", "kid", signingKey.ID, "error", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ActivatesAt.Before(keys[j].ActivatesAt)
+	})
+	return keys, nil
+}
+
+func (s *Store) generate(ctx context.Context, activatesAt time.Time) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch s.config.Algorithm {
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	kid := base64.RawURLEncoding.EncodeToString(id)
+	sealed, err := s.sealer.seal(kid, der)
+	if err != nil {
+		return nil, err
+	}
+
+	// A key signs for one rotation interval plus the time it takes the next
+	// key to be created and published, then verifies for one token TTL.
+	signingKey := &model.SigningKey{
+		ID:          kid,
+		Algorithm:   s.config.Algorithm,
+		PrivateKey:  sealed,
+		CreatedAt:   time.Now(),
+		ActivatesAt: activatesAt,
+		ExpiresAt:   activatesAt.Add(s.config.RotationInterval + 2*s.config.KeyRefreshInterval + s.config.TTL),
+	}
+	if err := s.repository.Save(ctx, signingKey); err != nil {
+		return nil, err
+	}
+	return s.parseKey(signingKey)
+}
+
+func activeKey(keys []*Key, now time.Time) *Key {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].ActivatesAt.After(now) && keys[i].ExpiresAt.After(now) {
+			return keys[i]
+		}
+	}
+	return nil
+}
+
+// parseKey decrypts a stored key. Keys that fail to decrypt, including
+// any stored in plain text, are rejected, so write access to the database
+// alone cannot plant a signing key.
+func (s *Store) parseKey(signingKey *model.SigningKey) (*Key, error) {
+	der, err := s.sealer.open(signingKey.ID, signingKey.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if signingKey.Algorithm != "RS256" {
+			return nil, fmt.Errorf("RSA key cannot sign %s", signingKey.Algorithm)
+		}
+	case *ecdsa.PrivateKey:
+		if signingKey.Algorithm != "ES256" {
+			return nil, fmt.Errorf("ECDSA key cannot sign %s", signingKey.Algorithm)
+		}
+	case ed25519.PrivateKey:
+		if signingKey.Algorithm != "EdDSA" {
+			return nil, fmt.Errorf("Ed25519 key cannot sign %s", signingKey.Algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+
+	return &Key{
+		ID:          signingKey.ID,
+		Algorithm:   signingKey.Algorithm,
+		Private:     private,
+		Public:      private.Public(),
+		ActivatesAt: signingKey.ActivatesAt,
+		ExpiresAt:   signingKey.ExpiresAt,
+	}, nil
+}
+
+// JSONWebKey is the RFC 7517 representation of a public key.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func publicJWK(key *Key) JSONWebKey {
+	jwk := JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = public.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
@@ -0,0 +1,59 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// sealer encrypts private keys at rest with AES-256-GCM under a key derived
+// from the configured key encryption key. The key ID is authenticated with
+// each ciphertext, so a sealed key cannot be moved to another document.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(keyEncryptionKey string) (*sealer, error) {
+	if keyEncryptionKey == "" {
+		return nil, errors.New("a key encryption key is required")
+	}
+	key := sha256.Sum256([]byte(keyEncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// seal returns the nonce and ciphertext of plaintext, base64 encoded.
+func (s *sealer) seal(kid string, plaintext []byte) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, plaintext, []byte(kid))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *sealer) open(kid, sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < s.aead.NonceSize() {
+		return nil, errors.New("sealed key is truncated")
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(kid))
+	if err != nil {
+		return nil, errors.New("private key cannot be decrypted with the configured key encryption key")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"Student-Assistant-App/src/config"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// WithRequestID returns a context carrying the request ID so that every log
+// line written with it can be correlated.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// New builds the application logger. Output is JSON unless the format is
+// "text", and attributes that carry personal data or credentials are
+// redacted before they are written.
+func New(logConfig config.LogConfig) *slog.Logger {
+	return newLogger(os.Stdout, logConfig)
+}
+
+func newLogger(w io.Writer, logConfig config.LogConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       ParseLevel(logConfig.Level),
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(logConfig.Format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// contextHandler adds the request ID and trace context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+const redacted = "[REDACTED]"
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	switch strings.ToLower(attr.Key) {
+	case "email", "to", "recipient":
+		if attr.Value.Kind() == slog.KindString {
+			return slog.String(attr.Key, RedactEmail(attr.Value.String()))
+		}
+	case "token", "authorization", "password", "otp", "code", "secret":
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// RedactEmail keeps just enough of an address to tell users apart in logs:
+// "alice@uni.edu" becomes "a***@uni.edu".
+func RedactEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
@@ -15,6 +15,5 @@ func MapToUser(req *request.CreateUserRequest) (*model.User, error) {
 	return &model.User{
 		Password: req.Password,
 		Email:    email,
-		Role:     req.Role,
 	}, nil
 }
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "student_assistant"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	LoginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "login_attempts_total",
+		Help:      "Password login attempts by result.",
+	}, []string{"result"})
+
+	OTPEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "otp",
+		Name:      "events_total",
+		Help:      "OTPs issued, verified and failed by purpose.",
+	}, []string{"purpose", "event"})
+
+	EmailSendDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "email",
+		Name:      "send_duration_seconds",
+		Help:      "Time spent delivering email to the SMTP server by kind.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"kind"})
+
+	EmailFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "email",
+		Name:      "send_failures_total",
+		Help:      "Failed email deliveries by kind.",
+	}, []string{"kind"})
+
+	MongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "operation_duration_seconds",
+		Help:      "Repository operation latency by collection and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"collection", "operation"})
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	OTPIssued   = "issued"
+	OTPVerified = "verified"
+	OTPFailed   = "failed"
+)
+
+// TimeMongo starts timing a repository operation; call the returned function
+// when the operation completes:
+//
+//	defer metrics.TimeMongo("users", "find_by_id")()
+func TimeMongo(collection, operation string) func() {
+	start := time.Now()
+	return func() {
+		MongoOperationDuration.WithLabelValues(collection, operation).Observe(time.Since(start).Seconds())
+	}
+}
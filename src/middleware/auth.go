@@ -1,44 +1,130 @@
 package middleware
 
 import (
-	"Student-Assistant-App/src/utils"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/session"
+	"Student-Assistant-App/src/tenant"
+	"Student-Assistant-App/src/tokens"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware authenticates "Bearer <jwt>" and "ApiKey <key>" requests,
+// and in cookie session mode requests carrying the session cookie. API key
+// requests are further limited by the key's scopes; cookie requests that
+// change state must carry a valid CSRF token.
+func AuthMiddleware(tokenManager tokens.Manager, apiKeyService service.APIKeyService, sessions *session.Cookies) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
-		if authHeader == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Authorization header required"})
-			ctx.Abort()
-			return
+		var tokenParts []string
+		if token, ok := sessions.Token(ctx); ok && authHeader == "" {
+			if !isSafeMethod(ctx.Request.Method) && !sessions.ValidCSRF(ctx, token) {
+				ctx.JSON(http.StatusForbidden, gin.H{"message": "Missing or invalid CSRF token"})
+				ctx.Abort()
+				return
+			}
+			tokenParts = []string{"Bearer", token}
+		} else {
+			if authHeader == "" {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Authorization header required"})
+				ctx.Abort()
+				return
+			}
+
+			tokenParts = strings.Split(authHeader, " ")
+			if len(tokenParts) != 2 || (tokenParts[0] != "Bearer" && tokenParts[0] != "ApiKey") {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid authorization header format"})
+				ctx.Abort()
+				return
+			}
 		}
 
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid authorization header format"})
-			ctx.Abort()
+		if tokenParts[0] == "ApiKey" {
+			user, apiKey, err := apiKeyService.Authenticate(ctx.Request.Context(), tokenParts[1])
+			if err != nil {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired API key"})
+				ctx.Abort()
+				return
+			}
+
+			scope := enums.ScopeWrite
+			if isSafeMethod(ctx.Request.Method) {
+				scope = enums.ScopeRead
+			}
+			if !apiKey.HasScope(scope) {
+				ctx.JSON(http.StatusForbidden, gin.H{"message": "API key lacks the " + string(scope) + " scope"})
+				ctx.Abort()
+				return
+			}
+
+			ctx.Set("apiKeyScopes", apiKey.Scopes)
+			setIdentity(ctx, user.ID.Hex(), user.Email, user.Role, user.InstitutionID)
+			ctx.Next()
 			return
 		}
 
-		token := tokenParts[1]
-		claims, err := utils.ValidateJWT(token)
+		claims, err := tokenManager.Validate(ctx.Request.Context(), tokenParts[1])
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
 			ctx.Abort()
 			return
 		}
 
-		ctx.Set("userID", claims.UserID)
-		ctx.Set("email", claims.Email)
-		ctx.Set("role", claims.Role)
+		setIdentity(ctx, claims.UserID, claims.Email, claims.Role, claims.InstitutionID)
 		ctx.Next()
 	}
 }
 
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func setIdentity(ctx *gin.Context, userID, email string, role enums.Role, institutionID string) {
+	ctx.Set("userID", userID)
+	ctx.Set("email", email)
+	ctx.Set("role", role)
+	ctx.Set("institutionID", institutionID)
+	if institutionID != "" {
+		ctx.Request = ctx.Request.WithContext(tenant.WithInstitution(ctx.Request.Context(), institutionID))
+	}
+}
+
+// SessionOnlyMiddleware rejects API key requests, so a leaked key cannot be
+// used to mint further keys.
+func SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, viaAPIKey := ctx.Get("apiKeyScopes"); viaAPIKey {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "API keys cannot be used for this endpoint"})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// lacksAdminScope reports whether the request uses an API key without the
+// admin scope. Such keys never reach the admin API, whatever the owner's role.
+func lacksAdminScope(ctx *gin.Context) bool {
+	scopes, viaAPIKey := ctx.Get("apiKeyScopes")
+	if !viaAPIKey {
+		return false
+	}
+	keyScopes, _ := scopes.([]enums.Scope)
+	return !slices.Contains(keyScopes, enums.ScopeAdmin)
+}
+
+// AdminMiddleware admits global admins and institution admins. Institution
+// admins stay scoped to their own institution by AuthMiddleware.
 func AdminMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		role, exists := ctx.Get("role")
@@ -48,7 +134,21 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if role != "ADMIN" {
+		if lacksAdminScope(ctx) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "API key lacks the admin scope"})
+			ctx.Abort()
+			return
+		}
+
+		switch role {
+		case enums.Admin:
+		case enums.InstitutionAdmin:
+			if ctx.GetString("institutionID") == "" {
+				ctx.JSON(http.StatusForbidden, gin.H{"message": "Institution admin is not assigned to an institution"})
+				ctx.Abort()
+				return
+			}
+		default:
 			ctx.JSON(http.StatusForbidden, gin.H{"message": "Admin access required"})
 			ctx.Abort()
 			return
@@ -56,4 +156,30 @@ func AdminMiddleware() gin.HandlerFunc {
 
 		ctx.Next()
 	}
-}
\ No newline at end of file
+}
+
+// SuperAdminMiddleware admits only global admins.
+func SuperAdminMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role, exists := ctx.Get("role")
+		if !exists {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": "User role not found"})
+			ctx.Abort()
+			return
+		}
+
+		if lacksAdminScope(ctx) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "API key lacks the admin scope"})
+			ctx.Abort()
+			return
+		}
+
+		if role != enums.Admin {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "Global admin access required"})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
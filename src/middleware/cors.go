@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"Student-Assistant-App/src/config"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSMiddleware answers preflight requests and adds CORS headers for the
+// configured origins. Requests from other origins get no CORS headers, so
+// browsers block them; non-browser clients are unaffected.
+func CORSMiddleware(corsConfig config.CORSConfig) gin.HandlerFunc {
+	allowMethods := strings.Join(corsConfig.AllowedMethods, ", ")
+	allowHeaders := strings.Join(corsConfig.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(corsConfig.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(corsConfig.MaxAge.Seconds()))
+
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" || len(corsConfig.AllowedOrigins) == 0 {
+			ctx.Next()
+			return
+		}
+
+		ctx.Writer.Header().Add("Vary", "Origin")
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+		if !originAllowed(corsConfig.AllowedOrigins, origin) {
+			if preflight {
+				ctx.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Access-Control-Allow-Origin", origin)
+		if corsConfig.AllowCredentials {
+			ctx.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			ctx.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			ctx.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			ctx.Header("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				ctx.Header("Access-Control-Allow-Headers", allowHeaders)
+			}
+			ctx.Header("Access-Control-Max-Age", maxAge)
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposeHeaders != "" {
+			ctx.Header("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		ctx.Next()
+	}
+}
+
+// originAllowed matches origin exactly, against "*", or against a
+// "scheme://*.domain" pattern covering any subdomain of domain.
+func originAllowed(allowedOrigins []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range allowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		scheme, domain, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
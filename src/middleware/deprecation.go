@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"Student-Assistant-App/src/config"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationMiddleware marks a deprecated endpoint with the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers, and links to its successor when
+// there is one. The dates are validated with the rest of the configuration.
+func DeprecationMiddleware(deprecationConfig config.DeprecationConfig, successor string) gin.HandlerFunc {
+	deprecation := "true"
+	if since, err := time.Parse(time.DateOnly, deprecationConfig.Since); err == nil {
+		deprecation = "@" + strconv.FormatInt(since.Unix(), 10)
+	}
+	sunset := ""
+	if date, err := time.Parse(time.DateOnly, deprecationConfig.Sunset); err == nil {
+		sunset = date.UTC().Format(http.TimeFormat)
+	}
+
+	return func(ctx *gin.Context) {
+		header := ctx.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		if sunset != "" {
+			header.Set("Sunset", sunset)
+		}
+		if successor != "" {
+			header.Add("Link", "<"+successor+">; rel=\"successor-version\"")
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"Student-Assistant-App/src/config"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeadersMiddleware sets browser security headers on every
+// response. HSTS is only sent over HTTPS, including behind a TLS-terminating
+// proxy, since browsers ignore it on plain HTTP.
+func SecurityHeadersMiddleware(securityConfig config.SecurityConfig) gin.HandlerFunc {
+	hsts := ""
+	if securityConfig.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(securityConfig.HSTSMaxAge.Seconds()))
+		if securityConfig.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(ctx *gin.Context) {
+		header := ctx.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		if securityConfig.FrameOptions != "" {
+			header.Set("X-Frame-Options", securityConfig.FrameOptions)
+		}
+		if securityConfig.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", securityConfig.ReferrerPolicy)
+		}
+		if hsts != "" && (ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https") {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		ctx.Next()
+	}
+}
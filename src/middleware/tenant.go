@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/tenant"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantMiddleware scopes requests that are not already scoped by their
+// token to the institution named in the tenant header or, failing that, the
+// request subdomain. Unknown institutions are rejected with 404.
+func TenantMiddleware(tenancyConfig config.TenancyConfig, institutionService service.InstitutionService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, scoped := tenant.InstitutionFromContext(ctx.Request.Context()); scoped {
+			ctx.Next()
+			return
+		}
+
+		institutionID := resolveInstitutionID(ctx.Request, tenancyConfig)
+		if institutionID == "" {
+			ctx.Next()
+			return
+		}
+
+		institution, err := institutionService.GetInstitution(ctx.Request.Context(), institutionID)
+		if err != nil {
+			if errors.Is(err, service.ErrInstitutionNotFound) {
+				ctx.JSON(http.StatusNotFound, gin.H{"message": "Institution not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to resolve institution"})
+			}
+			ctx.Abort()
+			return
+		}
+
+		ctx.Set("institutionID", institution.ID)
+		ctx.Request = ctx.Request.WithContext(tenant.WithInstitution(ctx.Request.Context(), institution.ID))
+		ctx.Next()
+	}
+}
+
+func resolveInstitutionID(request *http.Request, tenancyConfig config.TenancyConfig) string {
+	if tenancyConfig.Header != "" {
+		if institutionID := strings.TrimSpace(request.Header.Get(tenancyConfig.Header)); institutionID != "" {
+			return strings.ToLower(institutionID)
+		}
+	}
+
+	if tenancyConfig.BaseDomain == "" {
+		return ""
+	}
+	host := request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	subdomain, ok := strings.CutSuffix(host, "."+strings.ToLower(tenancyConfig.BaseDomain))
+	if !ok || strings.Contains(subdomain, ".") {
+		return ""
+	}
+	return subdomain
+}
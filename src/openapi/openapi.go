@@ -0,0 +1,257 @@
+// Package openapi generates the OpenAPI 3 document for the HTTP API from
+// the route table in Operations and the request and response types the
+// handlers bind and return.
+package openapi
+
+import (
+	"Student-Assistant-App/src/buildinfo"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Auth is the authentication an operation requires.
+type Auth int
+
+const (
+	// Public operations need no credentials.
+	Public Auth = iota
+	// Authenticated operations accept a bearer token, session cookie or API key.
+	Authenticated
+	// SessionOnly operations accept a bearer token or session cookie, but not
+	// an API key.
+	SessionOnly
+	// Admin operations require an admin or institution admin.
+	Admin
+	// SuperAdmin operations require a global admin.
+	SuperAdmin
+)
+
+// Param is a query parameter.
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// Operation describes one registered route. Path uses gin syntax, so
+// ":id" is a path parameter.
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tag         string
+	Auth        Auth
+	Query       []Param
+	// Request is an example of the JSON body the handler binds, if any.
+	Request any
+	// RequestFormats lists further accepted media types, beyond JSON.
+	RequestFormats []string
+	// Status is the success status code; Response an example of its JSON
+	// body, and ContentType set for non-JSON bodies.
+	Status      int
+	Response    any
+	ContentType string
+	Deprecated  bool
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type PathItem struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// errorSchema is the body of every error response.
+var errorSchema = &Schema{Ref: "#/components/schemas/Error"}
+
+// Build generates the document for operations.
+func Build(operations []Operation) *Document {
+	builder := newSchemaBuilder()
+	builder.components["Error"] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"message": {Type: "string"}},
+		Required:   []string{"message"},
+	}
+
+	document := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Student Assistant App API",
+			Version:     buildinfo.Get().Version,
+			Description: "Every /api/v1 route is also served under /api. Errors are returned as {\"message\": \"...\"} with the listed status codes.",
+		},
+		Paths: make(map[string]map[string]*PathItem),
+		Components: Components{
+			Schemas: builder.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKeyAuth": {Type: "apiKey", In: "header", Name: "Authorization", Description: `Personal API key sent as "ApiKey <key>".`},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "session", Description: "Session cookie in cookie session mode. State-changing requests must echo the CSRF cookie in X-CSRF-Token."},
+			},
+		},
+	}
+
+	for _, operation := range operations {
+		path, pathParams := openAPIPath(operation.Path)
+		if document.Paths[path] == nil {
+			document.Paths[path] = make(map[string]*PathItem)
+		}
+		document.Paths[path][strings.ToLower(operation.Method)] = buildPathItem(builder, operation, pathParams)
+	}
+	return document
+}
+
+func buildPathItem(builder *schemaBuilder, operation Operation, pathParams []string) *PathItem {
+	item := &PathItem{
+		OperationID: operationID(operation),
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		Responses:   make(map[string]*Response),
+		Deprecated:  operation.Deprecated,
+	}
+	if operation.Tag != "" {
+		item.Tags = []string{operation.Tag}
+	}
+
+	for _, name := range pathParams {
+		item.Parameters = append(item.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for _, param := range operation.Query {
+		item.Parameters = append(item.Parameters, Parameter{Name: param.Name, In: "query", Description: param.Description, Schema: &Schema{Type: param.Type}})
+	}
+
+	if operation.Request != nil || len(operation.RequestFormats) > 0 {
+		item.RequestBody = &RequestBody{Required: true, Content: make(map[string]*MediaType)}
+		if operation.Request != nil {
+			item.RequestBody.Content["application/json"] = &MediaType{Schema: builder.schemaOf(operation.Request)}
+		}
+		for _, format := range operation.RequestFormats {
+			schema := &Schema{Type: "string"}
+			if format == "multipart/form-data" {
+				schema = &Schema{Type: "object", Properties: map[string]*Schema{"file": {Type: "string", Format: "binary"}}, Required: []string{"file"}}
+			}
+			item.RequestBody.Content[format] = &MediaType{Schema: schema}
+		}
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	switch {
+	case operation.ContentType != "":
+		success.Content = map[string]*MediaType{operation.ContentType: {Schema: &Schema{Type: "string"}}}
+	case operation.Response != nil:
+		success.Content = map[string]*MediaType{"application/json": {Schema: builder.schemaOf(operation.Response)}}
+	}
+	item.Responses[strconv.Itoa(status)] = success
+
+	errorStatuses := []int{http.StatusBadRequest}
+	switch operation.Auth {
+	case Authenticated, SessionOnly:
+		errorStatuses = append(errorStatuses, http.StatusUnauthorized, http.StatusForbidden)
+		item.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+		if operation.Auth == Authenticated {
+			item.Security = append(item.Security, map[string][]string{"apiKeyAuth": {}})
+		}
+	case Admin, SuperAdmin:
+		errorStatuses = append(errorStatuses, http.StatusUnauthorized, http.StatusForbidden)
+		item.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}, {"apiKeyAuth": {}}}
+	}
+	for _, errorStatus := range errorStatuses {
+		item.Responses[strconv.Itoa(errorStatus)] = &Response{
+			Description: http.StatusText(errorStatus),
+			Content:     map[string]*MediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+	return item
+}
+
+// openAPIPath converts gin path parameters to OpenAPI templates and returns
+// the parameter names.
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a stable ID such as "post_api_auth_login".
+func operationID(operation Operation) string {
+	replacer := strings.NewReplacer("/", "_", ":", "", "-", "_", ".", "_", "*", "")
+	return strings.ToLower(operation.Method) + strings.TrimRight(replacer.Replace(operation.Path), "_")
+}
+
+// Routes returns "METHOD path" for every operation, in gin path syntax, so
+// the spec can be compared with the routes registered on the router.
+func Routes(operations []Operation) []string {
+	routes := make([]string, 0, len(operations))
+	for _, operation := range operations {
+		routes = append(routes, operation.Method+" "+operation.Path)
+	}
+	slices.Sort(routes)
+	return routes
+}
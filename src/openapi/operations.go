@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"Student-Assistant-App/src/buildinfo"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/dtos/response"
+	"Student-Assistant-App/src/health"
+	"Student-Assistant-App/src/keys"
+	"net/http"
+)
+
+// message is the envelope of handlers that respond with only a message.
+var message = Fields{"message": ""}
+
+// Operations lists every route the server registers, with API routes under
+// their /api/v1 path. Keep it in step with the router package.
+func Operations() []Operation {
+	return []Operation{
+		{Method: http.MethodGet, Path: "/healthz", Tag: "health", Summary: "Liveness probe",
+			Response: Fields{"status": ""}},
+		{Method: http.MethodGet, Path: "/readyz", Tag: "health", Summary: "Readiness probe",
+			Response: Fields{"status": ""}},
+		{Method: http.MethodGet, Path: "/metrics", Tag: "health", Summary: "Prometheus metrics",
+			ContentType: "text/plain"},
+		{Method: http.MethodGet, Path: "/.well-known/jwks.json", Tag: "auth", Summary: "Public keys that verify issued tokens",
+			Response: keys.JSONWebKeySet{}},
+		{Method: http.MethodGet, Path: "/openapi.json", Tag: "docs", Summary: "This OpenAPI document",
+			Response: Fields{}},
+		{Method: http.MethodGet, Path: "/docs", Tag: "docs", Summary: "API reference page",
+			ContentType: "text/html"},
+
+		{Method: http.MethodPost, Path: "/api/v1/auth/signup", Tag: "auth", Summary: "Sign up with email and password", Deprecated: true,
+			Description: "Use /api/v1/auth/signup-with-otp instead. Responses carry Deprecation and Sunset headers.",
+			Request:     request.CreateUserRequest{}, Status: http.StatusCreated, Response: response.CreateUserResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/auth/login", Tag: "auth", Summary: "Log in with email and password", Deprecated: true,
+			Description: "Use /api/v1/auth/login-with-otp instead. Responses carry Deprecation and Sunset headers.",
+			Request:     request.LoginRequest{}, Response: response.LoginResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/auth/logout", Tag: "auth", Summary: "Clear the session cookies",
+			Response: message},
+		{Method: http.MethodPost, Path: "/api/v1/auth/send-otp", Tag: "auth", Summary: "Email a one-time code",
+			Request: request.SendOTPRequest{}, Response: message},
+		{Method: http.MethodPost, Path: "/api/v1/auth/verify-otp", Tag: "auth", Summary: "Verify a one-time code",
+			Request: request.VerifyOTPRequest{}, Response: message},
+		{Method: http.MethodPost, Path: "/api/v1/auth/resend-otp", Tag: "auth", Summary: "Resend a one-time code",
+			Description: "Only signup, login and password_reset codes can be resent.",
+			Request:     request.SendOTPRequest{}, Response: message},
+		{Method: http.MethodPost, Path: "/api/v1/auth/signup-with-otp", Tag: "auth", Summary: "Sign up with a one-time code",
+			Request: request.SignupWithOTPRequest{}, Status: http.StatusCreated, Response: response.CreateUserResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/auth/login-with-otp", Tag: "auth", Summary: "Log in with a one-time code",
+			Request: request.LoginWithOTPRequest{}, Response: response.LoginResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/auth/set-password", Tag: "auth", Summary: "Set a password with a one-time code",
+			Request: request.SetPasswordRequest{}, Response: message},
+		{Method: http.MethodPost, Path: "/api/v1/auth/accept-invitation", Tag: "auth", Summary: "Create an account from an invitation",
+			Request: request.AcceptInvitationRequest{}, Status: http.StatusCreated, Response: response.CreateUserResponse{}},
+		{Method: http.MethodGet, Path: "/api/v1/auth/oidc/providers", Tag: "auth", Summary: "List SSO providers",
+			Response: Fields{"message": "", "providers": []string{}}},
+		{Method: http.MethodGet, Path: "/api/v1/auth/oidc/:provider/login", Tag: "auth", Summary: "Start SSO login",
+			Description: "Redirects to the provider.", Status: http.StatusFound},
+		{Method: http.MethodGet, Path: "/api/v1/auth/oidc/:provider/callback", Tag: "auth", Summary: "Complete SSO login",
+			Description: "Redirects to the frontend with the token in the URL fragment when a frontend URL is configured; otherwise responds with the login response.",
+			Query:       []Param{{Name: "code", Type: "string"}, {Name: "state", Type: "string"}, {Name: "error", Type: "string"}},
+			Response:    response.LoginResponse{}},
+
+		{Method: http.MethodGet, Path: "/api/v1/users/me", Tag: "users", Auth: Authenticated, Summary: "Get the current user",
+			Response: Fields{"message": "", "user": model.User{}}},
+		{Method: http.MethodGet, Path: "/api/v1/users/:id", Tag: "users", Auth: Authenticated, Summary: "Get a user",
+			Response: Fields{"message": "", "user": model.User{}}},
+		{Method: http.MethodPut, Path: "/api/v1/users/:id", Tag: "users", Auth: Authenticated, Summary: "Update a user",
+			Request: request.UpdateUserRequest{}, Response: Fields{"message": "", "user": model.User{}}},
+		{Method: http.MethodDelete, Path: "/api/v1/users/:id", Tag: "users", Auth: Authenticated, Summary: "Delete a user",
+			Response: message},
+
+		{Method: http.MethodGet, Path: "/api/v1/users/me/api-keys", Tag: "api-keys", Auth: SessionOnly, Summary: "List your API keys",
+			Response: Fields{"message": "", "api_keys": []model.APIKey{}}},
+		{Method: http.MethodPost, Path: "/api/v1/users/me/api-keys", Tag: "api-keys", Auth: SessionOnly, Summary: "Create an API key",
+			Description: "The key is returned only in this response.",
+			Request:     request.CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: response.CreateAPIKeyResponse{}},
+		{Method: http.MethodDelete, Path: "/api/v1/users/me/api-keys/:id", Tag: "api-keys", Auth: SessionOnly, Summary: "Revoke an API key",
+			Response: message},
+
+		{Method: http.MethodGet, Path: "/api/v1/admin/users", Tag: "admin", Auth: Admin, Summary: "List users",
+			Response: Fields{"message": "", "users": []model.User{}}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/users/duplicates", Tag: "admin", Auth: Admin, Summary: "List emails shared by several users",
+			Response: Fields{"message": "", "duplicates": []repository.DuplicateEmail{}}},
+		{Method: http.MethodPost, Path: "/api/v1/admin/users/import", Tag: "admin", Auth: Admin, Summary: "Import users from CSV or JSON",
+			Description: "The body is a JSON array of users, a CSV document or a multipart upload with the file in the \"file\" field. Existing users can only be renamed; rows that change their role or set their password are rejected.",
+			Query: []Param{
+				{Name: "dry_run", Type: "boolean", Description: "Report what would change without writing."},
+				{Name: "invite", Type: "boolean", Description: "Queue a set-password code email for each created user."},
+			},
+			Request: []request.ImportUserRow{}, RequestFormats: []string{"text/csv", "multipart/form-data"},
+			Response: response.ImportUsersResponse{}},
+		{Method: http.MethodPut, Path: "/api/v1/admin/users/:id/role", Tag: "admin", Auth: Admin, Summary: "Change a user's role",
+			Request: request.SetRoleRequest{}, Response: Fields{"message": "", "user": model.User{}}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/users/export", Tag: "admin", Auth: Admin, Summary: "Export users as CSV",
+			Query: []Param{
+				{Name: "role", Type: "string"},
+				{Name: "institution_id", Type: "string"},
+				{Name: "search", Type: "string", Description: "Matches name or email."},
+			},
+			ContentType: "text/csv"},
+		{Method: http.MethodGet, Path: "/api/v1/admin/invitations", Tag: "admin", Auth: Admin, Summary: "List invitations",
+			Response: Fields{"message": "", "invitations": []model.Invitation{}}},
+		{Method: http.MethodPost, Path: "/api/v1/admin/invitations", Tag: "admin", Auth: Admin, Summary: "Invite a user",
+			Request: request.CreateInvitationRequest{}, Status: http.StatusCreated, Response: Fields{"message": "", "invitation": model.Invitation{}}},
+		{Method: http.MethodDelete, Path: "/api/v1/admin/invitations/:id", Tag: "admin", Auth: Admin, Summary: "Revoke an invitation",
+			Response: message},
+		{Method: http.MethodGet, Path: "/api/v1/admin/health", Tag: "admin", Auth: SuperAdmin, Summary: "Detailed health report",
+			Response: Fields{"message": "", "status": "", "ready": false, "checks": []health.Result{}, "build": buildinfo.Info{}}},
+
+		{Method: http.MethodGet, Path: "/api/v1/admin/institutions", Tag: "institutions", Auth: SuperAdmin, Summary: "List institutions",
+			Response: Fields{"message": "", "institutions": []model.Institution{}}},
+		{Method: http.MethodPost, Path: "/api/v1/admin/institutions", Tag: "institutions", Auth: SuperAdmin, Summary: "Create an institution",
+			Request: request.CreateInstitutionRequest{}, Status: http.StatusCreated, Response: Fields{"message": "", "institution": model.Institution{}}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/institutions/:id", Tag: "institutions", Auth: SuperAdmin, Summary: "Get an institution",
+			Response: Fields{"message": "", "institution": model.Institution{}}},
+		{Method: http.MethodPut, Path: "/api/v1/admin/institutions/:id", Tag: "institutions", Auth: SuperAdmin, Summary: "Update an institution",
+			Request: request.UpdateInstitutionRequest{}, Response: Fields{"message": "", "institution": model.Institution{}}},
+		{Method: http.MethodDelete, Path: "/api/v1/admin/institutions/:id", Tag: "institutions", Auth: SuperAdmin, Summary: "Delete an institution",
+			Response: message},
+	}
+}
@@ -0,0 +1,178 @@
+package openapi
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Schema is the subset of the OpenAPI 3 schema object the generator emits.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// Fields describes an ad hoc JSON object, such as the gin.H envelopes most
+// handlers respond with. Each value is an example of the field's Go type.
+type Fields map[string]any
+
+// enumValues lists the allowed values of string enum types.
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeOf(enums.Role("")): {
+		string(enums.Admin), string(enums.InstitutionAdmin), string(enums.User), string(enums.Guest),
+	},
+	reflect.TypeOf(enums.Scope("")): {
+		string(enums.ScopeRead), string(enums.ScopeWrite), string(enums.ScopeAdmin),
+	},
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// schemaBuilder reflects Go types into schemas. Named structs become shared
+// components referenced by name.
+type schemaBuilder struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf returns the schema for an example value, a Fields object or nil.
+func (b *schemaBuilder) schemaOf(value any) *Schema {
+	if value == nil {
+		return nil
+	}
+	if fields, ok := value.(Fields); ok {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for name, field := range fields {
+			schema.Properties[name] = b.schemaOf(field)
+			schema.Required = append(schema.Required, name)
+		}
+		slices.Sort(schema.Required)
+		return schema
+	}
+	return b.schemaFor(reflect.TypeOf(value))
+}
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) *Schema {
+	if values, ok := enumValues[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return &Schema{Type: "string", Pattern: "^[0-9a-f]{24}$"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schemaFor(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + b.component(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// component registers a named struct once and returns its component name.
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := b.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	b.names[t] = name
+	b.components[name] = &Schema{}
+	*b.components[name] = *b.structSchema(t)
+	return name
+}
+
+// structSchema follows encoding/json: embedded structs are flattened, "-"
+// fields are skipped and the json tag names the property. Fields with a
+// binding:"required" tag, and response fields without omitempty, are
+// required.
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(field.Type)
+			for property, propertySchema := range embedded.Properties {
+				schema.Properties[property] = propertySchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = b.schemaFor(field.Type)
+		_, hasBinding := field.Tag.Lookup("binding")
+		if strings.Contains(field.Tag.Get("binding"), "required") ||
+			(!hasBinding && !strings.Contains(options, "omitempty") && !isRequestType(t)) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	slices.Sort(schema.Required)
+	return schema
+}
+
+// isRequestType reports whether t is a request DTO, whose fields are only
+// required when marked with binding:"required".
+func isRequestType(t reflect.Type) bool {
+	return strings.HasSuffix(t.PkgPath(), "/dtos/request")
+}
@@ -0,0 +1,152 @@
+// Package router registers the HTTP routes. The API is served under
+// /api/v1, and under /api as an alias for clients written before the API was
+// versioned.
+package router
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/controller"
+	"Student-Assistant-App/src/middleware"
+	"Student-Assistant-App/src/service"
+	"Student-Assistant-App/src/session"
+	"Student-Assistant-App/src/tokens"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+const (
+	// V1Prefix is the base path of version 1 of the API.
+	V1Prefix = "/api/v1"
+	// AliasPrefix serves the current version without a version segment.
+	AliasPrefix = "/api"
+)
+
+// Prefixes lists every base path the API is mounted at.
+var Prefixes = []string{V1Prefix, AliasPrefix}
+
+// Controllers are the handlers the routes dispatch to.
+type Controllers struct {
+	User        *controller.UserController
+	Institution *controller.InstitutionController
+	Invitation  *controller.InvitationController
+	OIDC        *controller.OIDCController
+	APIKey      *controller.APIKeyController
+	Key         *controller.KeyController
+	Docs        *controller.DocsController
+	Health      *controller.HealthController
+}
+
+// Dependencies are what the middleware needs.
+type Dependencies struct {
+	Config             *config.Config
+	Logger             *slog.Logger
+	TokenManager       tokens.Manager
+	APIKeyService      service.APIKeyService
+	InstitutionService service.InstitutionService
+	Sessions           *session.Cookies
+}
+
+// New builds the router with every route registered.
+func New(deps Dependencies, controllers Controllers) *gin.Engine {
+	router := gin.New()
+	router.Use(
+		gin.Recovery(),
+		otelgin.Middleware(deps.Config.Tracing.ServiceName),
+		middleware.SecurityHeadersMiddleware(deps.Config.Security),
+		middleware.CORSMiddleware(deps.Config.CORS),
+		middleware.RequestIDMiddleware(),
+		middleware.LoggerMiddleware(deps.Logger),
+		middleware.MetricsMiddleware(),
+	)
+
+	router.GET("/healthz", controllers.Health.Healthz)
+	router.GET("/readyz", controllers.Health.Readyz)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/.well-known/jwks.json", controllers.Key.JWKS)
+	router.GET("/openapi.json", controllers.Docs.OpenAPI)
+	router.GET("/docs", controllers.Docs.Docs)
+
+	for _, prefix := range Prefixes {
+		registerAPI(router, prefix, deps, controllers)
+	}
+	return router
+}
+
+// registerAPI mounts the API at prefix.
+func registerAPI(router *gin.Engine, prefix string, deps Dependencies, controllers Controllers) {
+	deprecated := func(successor string) gin.HandlerFunc {
+		return middleware.DeprecationMiddleware(deps.Config.Deprecation, prefix+successor)
+	}
+
+	public := router.Group(prefix)
+	public.Use(middleware.TenantMiddleware(deps.Config.Tenancy, deps.InstitutionService))
+	{
+		public.POST("/auth/signup", deprecated("/auth/signup-with-otp"), controllers.User.Signup)
+		public.POST("/auth/login", deprecated("/auth/login-with-otp"), controllers.User.Login)
+		public.POST("/auth/logout", controllers.User.Logout)
+		public.POST("/auth/send-otp", controllers.User.SendOTP)
+		public.POST("/auth/verify-otp", controllers.User.VerifyOTP)
+		public.POST("/auth/resend-otp", controllers.User.ResendOTP)
+		public.POST("/auth/signup-with-otp", controllers.User.SignupWithOTP)
+		public.POST("/auth/login-with-otp", controllers.User.LoginWithOTP)
+		public.POST("/auth/set-password", controllers.User.SetPassword)
+		public.POST("/auth/accept-invitation", controllers.Invitation.AcceptInvitation)
+		public.GET("/auth/oidc/providers", controllers.OIDC.GetProviders)
+		public.GET("/auth/oidc/:provider/login", controllers.OIDC.StartLogin)
+		// Providers redirect to the unversioned callback registered with
+		// them whichever prefix the login started under, so the versioned
+		// route is never the redirect target.
+		public.GET("/auth/oidc/:provider/callback", controllers.OIDC.Callback)
+	}
+
+	api := router.Group(prefix)
+	api.Use(middleware.AuthMiddleware(deps.TokenManager, deps.APIKeyService, deps.Sessions), middleware.TenantMiddleware(deps.Config.Tenancy, deps.InstitutionService))
+	{
+		api.GET("/users/me", controllers.User.GetCurrentUser)
+		api.GET("/users/:id", controllers.User.GetUser)
+		api.PUT("/users/:id", controllers.User.UpdateUser)
+		api.DELETE("/users/:id", controllers.User.DeleteUser)
+
+		apiKeys := api.Group("/users/me/api-keys")
+		apiKeys.Use(middleware.SessionOnlyMiddleware())
+		{
+			apiKeys.GET("", controllers.APIKey.GetAPIKeys)
+			apiKeys.POST("", controllers.APIKey.CreateAPIKey)
+			apiKeys.DELETE("/:id", controllers.APIKey.RevokeAPIKey)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(middleware.AdminMiddleware())
+		{
+			admin.GET("/users", controllers.User.GetAllUsers)
+			admin.GET("/users/duplicates", controllers.User.GetDuplicateEmails)
+			admin.POST("/users/import", controllers.User.ImportUsers)
+			admin.PUT("/users/:id/role", controllers.User.SetUserRole)
+			admin.GET("/users/export", controllers.User.ExportUsers)
+			admin.GET("/invitations", controllers.Invitation.GetAllInvitations)
+			admin.POST("/invitations", controllers.Invitation.CreateInvitation)
+			admin.DELETE("/invitations/:id", controllers.Invitation.RevokeInvitation)
+		}
+
+		// Dependency state and build info are deployment-wide, so only
+		// global admins may see them.
+		superAdmin := api.Group("/admin")
+		superAdmin.Use(middleware.SuperAdminMiddleware())
+		{
+			superAdmin.GET("/health", controllers.Health.DetailedHealth)
+		}
+
+		institutions := api.Group("/admin/institutions")
+		institutions.Use(middleware.SuperAdminMiddleware())
+		{
+			institutions.GET("", controllers.Institution.GetAllInstitutions)
+			institutions.POST("", controllers.Institution.CreateInstitution)
+			institutions.GET("/:id", controllers.Institution.GetInstitution)
+			institutions.PUT("/:id", controllers.Institution.UpdateInstitution)
+			institutions.DELETE("/:id", controllers.Institution.DeleteInstitution)
+		}
+	}
+}
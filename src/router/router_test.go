@@ -0,0 +1,94 @@
+package router
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/controller"
+	"Student-Assistant-App/src/openapi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRoutesMatchOpenAPI keeps the published spec in step with the router:
+// every route must be documented and every documented operation served,
+// under both the versioned prefix and the alias.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := New(Dependencies{
+		Config: config.Default(),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, Controllers{})
+
+	served := make(map[string]bool)
+	aliased := make(map[string]bool)
+	for _, route := range engine.Routes() {
+		path := route.Path
+		if rest, ok := strings.CutPrefix(path, AliasPrefix+"/"); ok && !strings.HasPrefix(path, V1Prefix+"/") {
+			aliased[route.Method+" "+V1Prefix+"/"+rest] = true
+			continue
+		}
+		served[route.Method+" "+path] = true
+	}
+
+	documented := openapi.Routes(openapi.Operations())
+	for _, route := range documented {
+		if !served[route] {
+			t.Errorf("%s is documented but not served", route)
+		}
+		if strings.Contains(route, " "+V1Prefix+"/") && !aliased[route] {
+			t.Errorf("%s is documented but not served under %s", route, AliasPrefix)
+		}
+	}
+	for route := range served {
+		if !slices.Contains(documented, route) {
+			t.Errorf("%s is served but not documented", route)
+		}
+	}
+	for route := range aliased {
+		if !served[route] {
+			t.Errorf("%s is served under %s only", route, AliasPrefix)
+		}
+	}
+}
+
+func TestDocsPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name          string
+		integrity     string
+		want          int
+		wantIntegrity string
+	}{
+		{"with an integrity hash", "sha384-abc+/=", http.StatusOK, `integrity="sha384-abc&#43;/="`},
+		{"without one", "", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			docsConfig := config.Default().Docs
+			docsConfig.RedocIntegrity = test.integrity
+			docs, err := controller.NewDocsController(openapi.Build(openapi.Operations()), docsConfig)
+			if err != nil {
+				t.Fatalf("NewDocsController: %v", err)
+			}
+			engine := New(Dependencies{
+				Config: config.Default(),
+				Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}, Controllers{Docs: docs})
+
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+			if recorder.Code != test.want {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.want)
+			}
+			if body := recorder.Body.String(); test.wantIntegrity != "" && (!strings.Contains(body, test.wantIntegrity) || !strings.Contains(body, `crossorigin="anonymous"`)) {
+				t.Errorf("page does not pin the Redoc script:\n%s", body)
+			}
+		})
+	}
+}
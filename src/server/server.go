@@ -0,0 +1,79 @@
+package server
+
+import (
+	"Student-Assistant-App/src/config"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Readiness reports whether the process should receive traffic. It starts
+// unavailable, becomes ready once the server is listening and flips back
+// when shutdown begins.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
+
+type Server struct {
+	httpServer *http.Server
+	readiness  *Readiness
+	config     config.ServerConfig
+	logger     *slog.Logger
+}
+
+func New(serverConfig config.ServerConfig, handler http.Handler, readiness *Readiness, logger *slog.Logger) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              ":" + serverConfig.Port,
+			Handler:           handler,
+			ReadTimeout:       serverConfig.ReadTimeout,
+			ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+			WriteTimeout:      serverConfig.WriteTimeout,
+			IdleTimeout:       serverConfig.IdleTimeout,
+		},
+		readiness: readiness,
+		config:    serverConfig,
+		logger:    logger,
+	}
+}
+
+// Start serves HTTP until Shutdown is called. It returns nil after a clean
+// shutdown and the listener error otherwise.
+func (s *Server) Start() error {
+	s.logger.Info("starting server", "addr", s.httpServer.Addr)
+	s.readiness.SetReady(true)
+	err := s.httpServer.ListenAndServe()
+	s.readiness.SetReady(false)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown withdraws readiness, waits for the configured drain delay and then
+// lets in-flight requests finish until ctx expires.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.readiness.SetReady(false)
+
+	if s.config.DrainDelay > 0 {
+		s.logger.Info("draining before closing listeners", "delay", s.config.DrainDelay)
+		select {
+		case <-time.After(s.config.DrainDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return s.httpServer.Shutdown(ctx)
+}
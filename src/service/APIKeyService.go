@@ -0,0 +1,227 @@
+package service
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/dtos/response"
+	"Student-Assistant-App/src/tracing"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiKeyPrefix marks API keys so they are recognisable in logs and by
+// secret scanners. A key reads "saa_<prefix>_<secret>".
+const apiKeyPrefix = "saa_"
+
+// lastUsedResolution bounds how often a busy key's last-used time is written.
+const lastUsedResolution = time.Minute
+
+var (
+	ErrInvalidAPIKey     = errors.New("invalid or expired API key")
+	ErrAPIKeyNotFound    = errors.New("API key not found")
+	ErrTooManyAPIKeys    = errors.New("API key limit reached")
+	ErrAPIKeyScopeDenied = errors.New("only admins may create keys with the admin scope")
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID string, request *request.CreateAPIKeyRequest) (*response.CreateAPIKeyResponse, error)
+	GetAPIKeys(ctx context.Context, userID string) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id string) error
+	Authenticate(ctx context.Context, key string) (*model.User, *model.APIKey, error)
+}
+
+type APIKeyServiceImpl struct {
+	apiKeyRepository repository.APIKeyRepository
+	userRepository   repository.UserRepository
+	auditRepository  repository.AuditRepository
+	config           config.APIKeysConfig
+	logger           *slog.Logger
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository, auditRepo repository.AuditRepository, apiKeysConfig config.APIKeysConfig, logger *slog.Logger) APIKeyService {
+	return &APIKeyServiceImpl{
+		apiKeyRepository: apiKeyRepo,
+		userRepository:   userRepo,
+		auditRepository:  auditRepo,
+		config:           apiKeysConfig,
+		logger:           logger,
+	}
+}
+
+// CreateAPIKey generates a key for the user and returns it in full. Only
+// the prefix and a hash of the secret are stored, so it cannot be shown
+// again.
+func (s *APIKeyServiceImpl) CreateAPIKey(ctx context.Context, userID string, request *request.CreateAPIKeyRequest) (_ *response.CreateAPIKeyResponse, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.CreateAPIKey")
+	defer tracing.End(span, &err)
+
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" || len(name) > 100 {
+		return nil, errors.New("name must be between 1 and 100 characters")
+	}
+
+	scopes := []enums.Scope{enums.ScopeRead}
+	if len(request.Scopes) > 0 {
+		scopes = nil
+		for _, scope := range request.Scopes {
+			if !scope.IsValid() {
+				return nil, fmt.Errorf("invalid scope %q", scope)
+			}
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	if slices.Contains(scopes, enums.ScopeAdmin) && !user.Role.IsAdmin() {
+		return nil, ErrAPIKeyScopeDenied
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.DefaultTTL)
+	if request.ExpiresAt != nil {
+		expiresAt = *request.ExpiresAt
+		if !expiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		if expiresAt.After(now.Add(s.config.MaxTTL)) {
+			return nil, fmt.Errorf("expires_at must be within %s", s.config.MaxTTL)
+		}
+	}
+
+	count, err := s.apiKeyRepository.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(s.config.MaxPerUser) {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	secret, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := s.apiKeyRepository.Save(ctx, &model.APIKey{
+		UserID:     user.ID,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: utils.HashToken(secret),
+		Scopes:     scopes,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.auditRepository, s.logger, &model.AuditEvent{
+		Action:        "api_key.created",
+		ActorID:       userID,
+		TargetID:      apiKey.ID.Hex(),
+		InstitutionID: user.InstitutionID,
+		Details:       map[string]string{"name": name, "prefix": prefix},
+	})
+
+	return &response.CreateAPIKeyResponse{
+		Message: "API key created successfully. Store it now; it will not be shown again.",
+		APIKey:  apiKey,
+		Key:     apiKeyPrefix + prefix + "_" + secret,
+	}, nil
+}
+
+func (s *APIKeyServiceImpl) GetAPIKeys(ctx context.Context, userID string) (_ []*model.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.GetAPIKeys")
+	defer tracing.End(span, &err)
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.apiKeyRepository.FindByUserID(ctx, objectID)
+}
+
+func (s *APIKeyServiceImpl) RevokeAPIKey(ctx context.Context, userID, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.RevokeAPIKey")
+	defer tracing.End(span, &err)
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.apiKeyRepository.DeleteByIDAndUserID(ctx, id, objectID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPIKeyNotFound
+	}
+
+	recordAudit(ctx, s.auditRepository, s.logger, &model.AuditEvent{
+		Action:   "api_key.revoked",
+		ActorID:  userID,
+		TargetID: id,
+	})
+	return nil
+}
+
+// Authenticate resolves a full API key to its owner. The owner is loaded
+// on every request so role changes and deletions take effect immediately.
+func (s *APIKeyServiceImpl) Authenticate(ctx context.Context, key string) (_ *model.User, _ *model.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer tracing.End(span, &err)
+
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(key, apiKeyPrefix) || prefix == "" || secret == "" {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepository.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	if apiKey == nil || apiKey.IsExpired() ||
+		subtle.ConstantTimeCompare([]byte(utils.HashToken(secret)), []byte(apiKey.SecretHash)) != 1 {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepository.FindByID(ctx, apiKey.UserID.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > lastUsedResolution {
+		if err := s.apiKeyRepository.TouchLastUsed(ctx, apiKey.ID); err != nil {
+			s.logger.WarnContext(ctx, "failed to record API key use", "prefix", apiKey.Prefix, "error", err)
+		}
+	}
+	return user, apiKey, nil
+}
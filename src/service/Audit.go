@@ -0,0 +1,19 @@
+package service
+
+import (
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/logging"
+	"context"
+	"log/slog"
+)
+
+// recordAudit records event, logging rather than failing the caller when the
+// audit log cannot be written since the change itself has already happened.
+func recordAudit(ctx context.Context, auditRepository repository.AuditRepository, logger *slog.Logger, event *model.AuditEvent) {
+	event.RequestID = logging.RequestID(ctx)
+	logger.InfoContext(ctx, "audit", "action", event.Action, "actor_id", event.ActorID, "target_id", event.TargetID, "details", event.Details)
+	if err := auditRepository.Record(ctx, event); err != nil {
+		logger.ErrorContext(ctx, "failed to record audit event", "action", event.Action, "error", err)
+	}
+}
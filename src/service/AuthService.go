@@ -4,9 +4,13 @@ import (
 	"Student-Assistant-App/src/data/model"
 	"Student-Assistant-App/src/dtos/request"
 	"Student-Assistant-App/src/dtos/response"
+	"Student-Assistant-App/src/metrics"
+	"Student-Assistant-App/src/tokens"
+	"Student-Assistant-App/src/tracing"
 	"Student-Assistant-App/src/utils"
 	"context"
 	"errors"
+	"log/slog"
 )
 
 type AuthService interface {
@@ -15,16 +19,34 @@ type AuthService interface {
 }
 
 type AuthServiceImpl struct {
-	userService UserService
+	userService  UserService
+	tokenManager tokens.Manager
+	logger       *slog.Logger
 }
 
-func NewAuthService(userService UserService) AuthService {
+func NewAuthService(userService UserService, tokenManager tokens.Manager, logger *slog.Logger) AuthService {
 	return &AuthServiceImpl{
-		userService: userService,
+		userService:  userService,
+		tokenManager: tokenManager,
+		logger:       logger,
 	}
 }
 
-func (auth *AuthServiceImpl) Login(ctx context.Context, request *request.LoginRequest) (*response.LoginResponse, error) {
+func (auth *AuthServiceImpl) Login(ctx context.Context, request *request.LoginRequest) (_ *response.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer tracing.End(span, &err)
+
+	loginResponse, err := auth.login(ctx, request)
+	result := metrics.ResultSuccess
+	if err != nil {
+		result = metrics.ResultFailure
+		auth.logger.WarnContext(ctx, "login failed", "email", request.Email, "error", err)
+	}
+	metrics.LoginAttempts.WithLabelValues(result).Inc()
+	return loginResponse, err
+}
+
+func (auth *AuthServiceImpl) login(ctx context.Context, request *request.LoginRequest) (*response.LoginResponse, error) {
 	if request.Email == "" {
 		return nil, errors.New("email is required")
 	}
@@ -40,11 +62,14 @@ func (auth *AuthServiceImpl) Login(ctx context.Context, request *request.LoginRe
 		return nil, errors.New("invalid email or password")
 	}
 
-	if !utils.CheckPassword(request.Password, user.Password) {
+	_, checkSpan := tracing.Start(ctx, "bcrypt.CheckPassword")
+	passwordMatches := utils.CheckPassword(request.Password, user.Password)
+	checkSpan.End()
+	if !passwordMatches {
 		return nil, errors.New("invalid email or password")
 	}
 
-	token, err := utils.GenerateJWT(user.ID.Hex(), user.Email, user.Role)
+	token, err := auth.tokenManager.Issue(user.ID.Hex(), user.Email, user.Role, user.InstitutionID)
 	if err != nil {
 		return nil, err
 	}
@@ -57,5 +82,5 @@ func (auth *AuthServiceImpl) Login(ctx context.Context, request *request.LoginRe
 }
 
 func (auth *AuthServiceImpl) GenerateTokenForUser(user *model.User) (string, error) {
-	return utils.GenerateJWT(user.ID.Hex(), user.Email, user.Role)
-}
\ No newline at end of file
+	return auth.tokenManager.Issue(user.ID.Hex(), user.Email, user.Role, user.InstitutionID)
+}
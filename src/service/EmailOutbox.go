@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// JobQueue runs jobs in the background, after the request that queued them
+// has returned.
+type JobQueue interface {
+	// Enqueue reports whether there was room for job. Its error is logged.
+	Enqueue(ctx context.Context, job func(ctx context.Context) error) bool
+}
+
+// EmailOutbox is an EmailService that delivers non-critical mail (welcome
+// emails) in the background so requests don't wait on SMTP. OTP and
+// invitation emails are still sent inline because callers need to know
+// whether delivery failed. It is also a JobQueue for mail that is too slow
+// to send inline, such as invitations for a bulk import.
+type EmailOutbox struct {
+	emailService EmailService
+	queue        chan func() error
+	logger       *slog.Logger
+}
+
+func NewEmailOutbox(emailService EmailService, size int, logger *slog.Logger) *EmailOutbox {
+	return &EmailOutbox{
+		emailService: emailService,
+		queue:        make(chan func() error, size),
+		logger:       logger,
+	}
+}
+
+func (o *EmailOutbox) SendOTP(ctx context.Context, email, otp, purpose string, validFor time.Duration) error {
+	return o.emailService.SendOTP(ctx, email, otp, purpose, validFor)
+}
+
+// SendWelcomeEmail queues the email, or sends it inline when the outbox is
+// full.
+func (o *EmailOutbox) SendWelcomeEmail(ctx context.Context, email, name string) error {
+	send := func(ctx context.Context) error {
+		return o.emailService.SendWelcomeEmail(ctx, email, name)
+	}
+	if !o.Enqueue(ctx, send) {
+		o.logger.WarnContext(ctx, "email outbox full, sending inline")
+		o.deliver(func() error { return send(ctx) })
+	}
+	return nil
+}
+
+func (o *EmailOutbox) SendInvitation(ctx context.Context, email, inviterName, acceptLink string) error {
+	return o.emailService.SendInvitation(ctx, email, inviterName, acceptLink)
+}
+
+func (o *EmailOutbox) CheckConnection(ctx context.Context) error {
+	return o.emailService.CheckConnection(ctx)
+}
+
+// Enqueue queues job without waiting. Its context is detached from ctx's
+// cancellation so the job outlives the request but keeps its request ID.
+func (o *EmailOutbox) Enqueue(ctx context.Context, job func(ctx context.Context) error) bool {
+	ctx = context.WithoutCancel(ctx)
+	select {
+	case o.queue <- func() error { return job(ctx) }:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run delivers queued emails until ctx is cancelled, then drains whatever
+// is still queued before returning.
+func (o *EmailOutbox) Run(ctx context.Context) {
+	for {
+		select {
+		case send := <-o.queue:
+			o.deliver(send)
+		case <-ctx.Done():
+			for {
+				select {
+				case send := <-o.queue:
+					o.deliver(send)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (o *EmailOutbox) deliver(send func() error) {
+	if err := send(); err != nil {
+		o.logger.Error("failed to send queued email", "error", err)
+	}
+}
@@ -1,18 +1,28 @@
 package service
 
 import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/metrics"
+	"Student-Assistant-App/src/tracing"
+	"context"
 	"fmt"
-	"log"
-	"os"
+	"html"
+	"log/slog"
+	"net"
 	"strconv"
 	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/gomail.v2"
 )
 
 type EmailService interface {
-	SendOTP(email, otp, purpose string) error
-	SendWelcomeEmail(email, name string) error
+	// SendOTP emails otp, telling the recipient it expires after validFor.
+	SendOTP(ctx context.Context, email, otp, purpose string, validFor time.Duration) error
+	SendWelcomeEmail(ctx context.Context, email, name string) error
+	SendInvitation(ctx context.Context, email, inviterName, acceptLink string) error
+	CheckConnection(ctx context.Context) error
 }
 
 type EmailServiceImpl struct {
@@ -22,39 +32,30 @@ type EmailServiceImpl struct {
 	password string
 	from     string
 	dialer   *gomail.Dialer
+	logger   *slog.Logger
 	once     sync.Once
 }
 
-func NewEmailService() (EmailService, error) {
-	host := os.Getenv("EMAIL_HOST")
-	portStr := os.Getenv("EMAIL_PORT")
-	username := os.Getenv("EMAIL_USERNAME")
-	password := os.Getenv("EMAIL_PASSWORD")
-	from := os.Getenv("EMAIL_FROM")
-
-	if host == "" || portStr == "" || username == "" || password == "" || from == "" {
-		return nil, fmt.Errorf("email configuration missing")
-	}
-
-	port, err := strconv.Atoi(portStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid email port: %v", err)
-	}
-
-	dialer := gomail.NewDialer(host, port, username, password)
-
+func NewEmailService(emailConfig config.EmailConfig, logger *slog.Logger) EmailService {
+	dialer := gomail.NewDialer(emailConfig.Host, emailConfig.Port, emailConfig.Username, emailConfig.Password)
 
 	return &EmailServiceImpl{
-		host:     host,
-		port:     port,
-		username: username,
-		password: password,
-		from:     from,
+		host:     emailConfig.Host,
+		port:     emailConfig.Port,
+		username: emailConfig.Username,
+		password: emailConfig.Password,
+		from:     emailConfig.From,
 		dialer:   dialer,
-	}, nil
+		logger:   logger,
+	}
 }
 
-func (e *EmailServiceImpl) SendOTP(email, otp, purpose string) error {
+func (e *EmailServiceImpl) SendOTP(ctx context.Context, email, otp, purpose string, validFor time.Duration) (err error) {
+	ctx, span := tracing.Start(ctx, "EmailService.SendOTP")
+	defer tracing.End(span, &err)
+
+	expiry := expiryText(validFor)
+
 	subject := "Your OTP Code"
 	var body string
 
@@ -68,11 +69,11 @@ func (e *EmailServiceImpl) SendOTP(email, otp, purpose string) error {
 				<div style="background-color: #f0f0f0; padding: 20px; text-align: center; font-size: 24px; font-weight: bold; color: #333; border-radius: 5px; margin: 20px 0;">
 					%s
 				</div>
-				<p>This OTP will expire in 10 minutes.</p>
+				<p>This OTP will expire in %s.</p>
 				<p>If you didn't request this, please ignore this email.</p>
 			</body>
 			</html>
-		`, otp)
+		`, otp, expiry)
 	case "login":
 		body = fmt.Sprintf(`
 			<html>
@@ -82,11 +83,11 @@ func (e *EmailServiceImpl) SendOTP(email, otp, purpose string) error {
 				<div style="background-color: #f0f0f0; padding: 20px; text-align: center; font-size: 24px; font-weight: bold; color: #333; border-radius: 5px; margin: 20px 0;">
 					%s
 				</div>
-				<p>This OTP will expire in 10 minutes.</p>
+				<p>This OTP will expire in %s.</p>
 				<p>If you didn't request this, please ignore this email and secure your account.</p>
 			</body>
 			</html>
-		`, otp)
+		`, otp, expiry)
 	case "password_reset":
 		body = fmt.Sprintf(`
 			<html>
@@ -96,11 +97,26 @@ func (e *EmailServiceImpl) SendOTP(email, otp, purpose string) error {
 				<div style="background-color: #f0f0f0; padding: 20px; text-align: center; font-size: 24px; font-weight: bold; color: #333; border-radius: 5px; margin: 20px 0;">
 					%s
 				</div>
-				<p>This OTP will expire in 10 minutes.</p>
+				<p>This OTP will expire in %s.</p>
 				<p>If you didn't request this, please ignore this email.</p>
 			</body>
 			</html>
-		`, otp)
+		`, otp, expiry)
+	case "set_password":
+		subject = "You're invited to Student Assistant App"
+		body = fmt.Sprintf(`
+			<html>
+			<body>
+				<h2>Welcome to Student Assistant App!</h2>
+				<p>An account has been created for you. Use the following code to set your password:</p>
+				<div style="background-color: #f0f0f0; padding: 20px; text-align: center; font-size: 24px; font-weight: bold; color: #333; border-radius: 5px; margin: 20px 0;">
+					%s
+				</div>
+				<p>This code will expire in %s.</p>
+				<p>If you weren't expecting this invitation, please ignore this email.</p>
+			</body>
+			</html>
+		`, otp, expiry)
 	default:
 		body = fmt.Sprintf(`
 			<html>
@@ -110,16 +126,42 @@ func (e *EmailServiceImpl) SendOTP(email, otp, purpose string) error {
 				<div style="background-color: #f0f0f0; padding: 20px; text-align: center; font-size: 24px; font-weight: bold; color: #333; border-radius: 5px; margin: 20px 0;">
 					%s
 				</div>
-				<p>This OTP will expire in 10 minutes.</p>
+				<p>This OTP will expire in %s.</p>
 			</body>
 			</html>
-		`, otp)
+		`, otp, expiry)
 	}
 
-	return e.sendEmail(email, subject, body)
+	return e.sendEmail(ctx, "otp", email, subject, body)
 }
 
-func (e *EmailServiceImpl) SendWelcomeEmail(email, name string) error {
+// expiryText describes d in the largest whole unit, such as "10 minutes".
+func expiryText(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+		{time.Second, "second"},
+	}
+	for _, unit := range units {
+		if d >= unit.size && d%unit.size == 0 {
+			count := int64(d / unit.size)
+			if count == 1 {
+				return "1 " + unit.name
+			}
+			return strconv.FormatInt(count, 10) + " " + unit.name + "s"
+		}
+	}
+	return d.String()
+}
+
+func (e *EmailServiceImpl) SendWelcomeEmail(ctx context.Context, email, name string) (err error) {
+	ctx, span := tracing.Start(ctx, "EmailService.SendWelcomeEmail")
+	defer tracing.End(span, &err)
+
 	subject := "Welcome to Student Assistant App!"
 	body := fmt.Sprintf(`
 		<html>
@@ -132,21 +174,56 @@ func (e *EmailServiceImpl) SendWelcomeEmail(email, name string) error {
 		</html>
 	`, name)
 
-	return e.sendEmail(email, subject, body)
+	return e.sendEmail(ctx, "welcome", email, subject, body)
 }
 
-func (e *EmailServiceImpl) sendEmail(to, subject, body string) error {
+func (e *EmailServiceImpl) SendInvitation(ctx context.Context, email, inviterName, acceptLink string) (err error) {
+	ctx, span := tracing.Start(ctx, "EmailService.SendInvitation")
+	defer tracing.End(span, &err)
+
+	subject := "You're invited to Student Assistant App"
+	body := fmt.Sprintf(`
+		<html>
+		<body>
+			<h2>You're invited!</h2>
+			<p>%s has invited you to join Student Assistant App.</p>
+			<p><a href="%s">Accept your invitation</a> to create your account.</p>
+			<p>If you weren't expecting this invitation, please ignore this email.</p>
+		</body>
+		</html>
+	`, html.EscapeString(inviterName), html.EscapeString(acceptLink))
+
+	return e.sendEmail(ctx, "invitation", email, subject, body)
+}
+
+// CheckConnection verifies that the SMTP server accepts TCP connections.
+func (e *EmailServiceImpl) CheckConnection(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(e.host, strconv.Itoa(e.port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (e *EmailServiceImpl) sendEmail(ctx context.Context, kind, to, subject, body string) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", e.from)
 	m.SetHeader("To", to)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/html", body)
 
-	if err := e.dialer.DialAndSend(m); err != nil {
-		log.Printf("failed to send email to %s: %v", to, err)
+	_, span := tracing.Start(ctx, "smtp.DialAndSend", attribute.String("email.kind", kind))
+	start := time.Now()
+	err := e.dialer.DialAndSend(m)
+	metrics.EmailSendDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	tracing.End(span, &err)
+	if err != nil {
+		metrics.EmailFailures.WithLabelValues(kind).Inc()
+		e.logger.ErrorContext(ctx, "failed to send email", "kind", kind, "to", to, "error", err)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
-	log.Printf("email sent successfully to %s with subject %s", to, subject)
+	e.logger.InfoContext(ctx, "email sent", "kind", kind, "to", to)
 	return nil
 }
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryText(t *testing.T) {
+	tests := []struct {
+		validFor time.Duration
+		want     string
+	}{
+		{2 * time.Minute, "2 minutes"},
+		{time.Minute, "1 minute"},
+		{90 * time.Second, "90 seconds"},
+		{72 * time.Hour, "3 days"},
+		{36 * time.Hour, "36 hours"},
+		{1500 * time.Millisecond, "1.5s"},
+	}
+	for _, test := range tests {
+		if got := expiryText(test.validFor); got != test.want {
+			t.Errorf("expiryText(%v) = %q, want %q", test.validFor, got, test.want)
+		}
+	}
+}
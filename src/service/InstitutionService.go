@@ -0,0 +1,207 @@
+package service
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/tracing"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+)
+
+var (
+	ErrInstitutionNotFound = errors.New("institution not found")
+	ErrInstitutionInUse    = errors.New("institution still has users")
+)
+
+// institutionIDRegex keeps IDs usable as subdomains and header values.
+var institutionIDRegex = regexp.MustCompile("^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$")
+
+type InstitutionService interface {
+	CreateInstitution(ctx context.Context, request *request.CreateInstitutionRequest) (*model.Institution, error)
+	GetInstitution(ctx context.Context, id string) (*model.Institution, error)
+	GetAllInstitutions(ctx context.Context) ([]*model.Institution, error)
+	UpdateInstitution(ctx context.Context, id string, request *request.UpdateInstitutionRequest) (*model.Institution, error)
+	DeleteInstitution(ctx context.Context, id string) error
+	// EnsureInstitutions creates the configured institutions that do not
+	// exist yet. Existing ones are left alone, since they may have been
+	// edited through the admin API.
+	EnsureInstitutions(ctx context.Context, institutions []config.InstitutionConfig) error
+}
+
+type InstitutionServiceImpl struct {
+	institutionRepository repository.InstitutionRepository
+	userRepository        repository.UserRepository
+	logger                *slog.Logger
+}
+
+func NewInstitutionService(institutionRepo repository.InstitutionRepository, userRepo repository.UserRepository, logger *slog.Logger) InstitutionService {
+	return &InstitutionServiceImpl{
+		institutionRepository: institutionRepo,
+		userRepository:        userRepo,
+		logger:                logger,
+	}
+}
+
+func (s *InstitutionServiceImpl) CreateInstitution(ctx context.Context, request *request.CreateInstitutionRequest) (_ *model.Institution, err error) {
+	ctx, span := tracing.Start(ctx, "InstitutionService.CreateInstitution")
+	defer tracing.End(span, &err)
+
+	if !institutionIDRegex.MatchString(request.ID) {
+		return nil, errors.New("institution ID must be a lower-case slug such as \"uni-a\"")
+	}
+	if request.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	domains, err := normalizeDomains(request.Domains)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.institutionRepository.FindByID(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, repository.ErrDuplicateInstitution
+	}
+
+	institution, err := s.institutionRepository.Create(ctx, &model.Institution{
+		ID:      request.ID,
+		Name:    request.Name,
+		Domains: domains,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "institution created", "institution_id", institution.ID)
+	return institution, nil
+}
+
+func (s *InstitutionServiceImpl) GetInstitution(ctx context.Context, id string) (_ *model.Institution, err error) {
+	ctx, span := tracing.Start(ctx, "InstitutionService.GetInstitution")
+	defer tracing.End(span, &err)
+
+	institution, err := s.institutionRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if institution == nil {
+		return nil, ErrInstitutionNotFound
+	}
+	return institution, nil
+}
+
+func (s *InstitutionServiceImpl) GetAllInstitutions(ctx context.Context) (_ []*model.Institution, err error) {
+	ctx, span := tracing.Start(ctx, "InstitutionService.GetAllInstitutions")
+	defer tracing.End(span, &err)
+
+	return s.institutionRepository.FindAll(ctx)
+}
+
+func (s *InstitutionServiceImpl) UpdateInstitution(ctx context.Context, id string, request *request.UpdateInstitutionRequest) (_ *model.Institution, err error) {
+	ctx, span := tracing.Start(ctx, "InstitutionService.UpdateInstitution")
+	defer tracing.End(span, &err)
+
+	institution, err := s.GetInstitution(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Name != "" {
+		institution.Name = request.Name
+	}
+	if request.Domains != nil {
+		domains, err := normalizeDomains(request.Domains)
+		if err != nil {
+			return nil, err
+		}
+		institution.Domains = domains
+	}
+
+	return s.institutionRepository.Save(ctx, institution)
+}
+
+// DeleteInstitution removes an institution that no longer has any users.
+func (s *InstitutionServiceImpl) DeleteInstitution(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "InstitutionService.DeleteInstitution")
+	defer tracing.End(span, &err)
+
+	if _, err := s.GetInstitution(ctx, id); err != nil {
+		return err
+	}
+
+	users, err := s.userRepository.CountByInstitution(ctx, id)
+	if err != nil {
+		return err
+	}
+	if users > 0 {
+		return ErrInstitutionInUse
+	}
+
+	if err := s.institutionRepository.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "institution deleted", "institution_id", id)
+	return nil
+}
+
+func normalizeDomains(domains []string) ([]string, error) {
+	var normalized []string
+	for _, domain := range domains {
+		if domain = utils.NormalizeDomain(domain); domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, errors.New("at least one domain is required")
+	}
+	return normalized, nil
+}
+
+func (s *InstitutionServiceImpl) EnsureInstitutions(ctx context.Context, institutions []config.InstitutionConfig) (err error) {
+	ctx, span := tracing.Start(ctx, "InstitutionService.EnsureInstitutions")
+	defer tracing.End(span, &err)
+
+	for _, configured := range institutions {
+		existing, err := s.institutionRepository.FindByID(ctx, configured.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		domains, err := normalizeDomains(configured.Domains)
+		if err != nil {
+			return fmt.Errorf("institution %q: %w", configured.ID, err)
+		}
+		name := configured.Name
+		if name == "" {
+			name = configured.ID
+		}
+		_, err = s.institutionRepository.Create(ctx, &model.Institution{
+			ID:      configured.ID,
+			Name:    name,
+			Domains: domains,
+		})
+		if errors.Is(err, repository.ErrDuplicateInstitution) {
+			// Created concurrently, or a domain now belongs to another
+			// institution; either way an admin has the final say.
+			s.logger.WarnContext(ctx, "configured institution not created", "institution_id", configured.ID, "error", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.logger.InfoContext(ctx, "institution created from configuration", "institution_id", configured.ID)
+	}
+	return nil
+}
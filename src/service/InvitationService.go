@@ -0,0 +1,223 @@
+package service
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/dtos/response"
+	"Student-Assistant-App/src/tenant"
+	"Student-Assistant-App/src/tracing"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrInvalidInvitation     = errors.New("invalid or expired invitation")
+	ErrInvitationRoleDenied  = errors.New("only global admins may invite global admins")
+	ErrInvitationUserExists  = errors.New("user already exists with this email")
+	ErrInstitutionAdminScope = errors.New("institution admins must belong to an institution")
+)
+
+type InvitationService interface {
+	CreateInvitation(ctx context.Context, inviter Actor, request *request.CreateInvitationRequest) (*model.Invitation, error)
+	GetAllInvitations(ctx context.Context) ([]*model.Invitation, error)
+	RevokeInvitation(ctx context.Context, id string) error
+	AcceptInvitation(ctx context.Context, request *request.AcceptInvitationRequest) (*response.CreateUserResponse, error)
+}
+
+type InvitationServiceImpl struct {
+	invitationRepository  repository.InvitationRepository
+	institutionRepository repository.InstitutionRepository
+	userService           UserService
+	signupPolicy          SignupPolicy
+	emailService          EmailService
+	config                config.InvitationsConfig
+	logger                *slog.Logger
+}
+
+func NewInvitationService(invitationRepo repository.InvitationRepository, institutionRepo repository.InstitutionRepository, userService UserService, signupPolicy SignupPolicy, emailService EmailService, invitationsConfig config.InvitationsConfig, logger *slog.Logger) InvitationService {
+	return &InvitationServiceImpl{
+		invitationRepository:  invitationRepo,
+		institutionRepository: institutionRepo,
+		userService:           userService,
+		signupPolicy:          signupPolicy,
+		emailService:          emailService,
+		config:                invitationsConfig,
+		logger:                logger,
+	}
+}
+
+// CreateInvitation stores an invitation and emails its accept link. The
+// invitee's institution is the inviter's own for institution admins, or the
+// requested one for global admins, and the email must be admissible to it
+// under the signup policy. Invited global admins belong to no institution,
+// so the policy does not apply to them.
+func (s *InvitationServiceImpl) CreateInvitation(ctx context.Context, inviter Actor, request *request.CreateInvitationRequest) (_ *model.Invitation, err error) {
+	ctx, span := tracing.Start(ctx, "InvitationService.CreateInvitation")
+	defer tracing.End(span, &err)
+
+	role := request.Role
+	if role == "" {
+		role = enums.User
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("invalid role %q", role)
+	}
+	if role == enums.Admin && inviter.Role != enums.Admin {
+		return nil, ErrInvitationRoleDenied
+	}
+
+	email, err := utils.EmailVerification(request.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	institutionID := ""
+	if role != enums.Admin {
+		if institutionID, err = s.checkPolicy(ctx, inviter, request.InstitutionID, email); err != nil {
+			return nil, err
+		}
+	}
+	if role == enums.InstitutionAdmin && institutionID == "" {
+		return nil, ErrInstitutionAdminScope
+	}
+
+	existingUser, err := s.userService.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existingUser != nil {
+		return nil, ErrInvitationUserExists
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.invitationRepository.Save(ctx, &model.Invitation{
+		TokenHash:     utils.HashToken(token),
+		Email:         email,
+		Role:          role,
+		InstitutionID: institutionID,
+		InvitedBy:     inviter.UserID,
+		ExpiresAt:     time.Now().Add(s.config.TTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inviterName := "An administrator"
+	if user, err := s.userService.GetUserByID(ctx, inviter.UserID); err == nil && user != nil && user.Name != "" {
+		inviterName = user.Name
+	}
+	if err := s.emailService.SendInvitation(ctx, email, inviterName, s.acceptLink(token)); err != nil {
+		// Nobody can use a token that was never delivered.
+		if deleteErr := s.invitationRepository.DeleteByID(ctx, invitation.ID.Hex()); deleteErr != nil {
+			s.logger.WarnContext(ctx, "failed to delete undelivered invitation", "invitation_id", invitation.ID.Hex(), "error", deleteErr)
+		}
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "invitation created",
+		"invitation_id", invitation.ID.Hex(), "email", email, "role", role, "invited_by", inviter.UserID)
+	return invitation, nil
+}
+
+// checkPolicy returns the institution the signup policy assigns email to,
+// checking it against the requested institution when a global admin names
+// one.
+func (s *InvitationServiceImpl) checkPolicy(ctx context.Context, inviter Actor, requestedInstitutionID, email string) (string, error) {
+	if _, scoped := tenant.InstitutionFromContext(ctx); !scoped && requestedInstitutionID != "" && inviter.Role == enums.Admin {
+		institution, err := s.institutionRepository.FindByID(ctx, requestedInstitutionID)
+		if err != nil {
+			return "", err
+		}
+		if institution == nil {
+			return "", ErrInstitutionNotFound
+		}
+		ctx = tenant.WithInstitution(ctx, institution.ID)
+	}
+	return s.signupPolicy.Check(ctx, email)
+}
+
+func (s *InvitationServiceImpl) GetAllInvitations(ctx context.Context) (_ []*model.Invitation, err error) {
+	ctx, span := tracing.Start(ctx, "InvitationService.GetAllInvitations")
+	defer tracing.End(span, &err)
+
+	return s.invitationRepository.FindAll(ctx)
+}
+
+func (s *InvitationServiceImpl) RevokeInvitation(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "InvitationService.RevokeInvitation")
+	defer tracing.End(span, &err)
+
+	if err := s.invitationRepository.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "invitation revoked", "invitation_id", id)
+	return nil
+}
+
+// AcceptInvitation creates the invited user with the role and institution
+// fixed by the invitation.
+func (s *InvitationServiceImpl) AcceptInvitation(ctx context.Context, acceptRequest *request.AcceptInvitationRequest) (_ *response.CreateUserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "InvitationService.AcceptInvitation")
+	defer tracing.End(span, &err)
+
+	invitation, err := s.invitationRepository.FindByTokenHash(ctx, utils.HashToken(acceptRequest.Token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || !invitation.IsValid() {
+		return nil, ErrInvalidInvitation
+	}
+
+	// Claim the invitation before creating the account, so a token that is
+	// accepted twice concurrently only ever creates one.
+	if err := s.invitationRepository.MarkAccepted(ctx, invitation.ID); err != nil {
+		if errors.Is(err, repository.ErrInvitationUnavailable) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	// Global admins are created like CreateAdmin's, outside any institution,
+	// even from invitations stored with one.
+	if invitation.InstitutionID != "" && invitation.Role != enums.Admin {
+		ctx = tenant.WithInstitution(ctx, invitation.InstitutionID)
+	}
+	created, err := s.userService.CreateUserWithRole(ctx, &request.CreateUserRequest{
+		Name:     acceptRequest.Name,
+		Email:    invitation.Email,
+		Password: acceptRequest.Password,
+	}, invitation.Role)
+	if err != nil {
+		// Let the invitee retry, e.g. with a stronger password.
+		if releaseErr := s.invitationRepository.ReleaseAccepted(ctx, invitation.ID); releaseErr != nil {
+			s.logger.WarnContext(ctx, "failed to release invitation", "invitation_id", invitation.ID.Hex(), "error", releaseErr)
+		}
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "invitation accepted", "invitation_id", invitation.ID.Hex(), "user_id", created.User.ID.Hex())
+	return created, nil
+}
+
+func (s *InvitationServiceImpl) acceptLink(token string) string {
+	link, err := url.Parse(s.config.AcceptURL)
+	if err != nil {
+		return s.config.AcceptURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
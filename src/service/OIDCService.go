@@ -0,0 +1,325 @@
+package service
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/dtos/response"
+	"Student-Assistant-App/src/metrics"
+	"Student-Assistant-App/src/tenant"
+	"Student-Assistant-App/src/tracing"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrUnknownProvider   = errors.New("unknown SSO provider")
+	ErrInvalidSSOState   = errors.New("SSO login expired or was not started here")
+	ErrUnverifiedSSO     = errors.New("SSO provider did not supply a verified email address")
+	ErrSSOAuthentication = errors.New("SSO authentication failed")
+	// ErrSSOLinkRefused is returned when the email belongs to an account the
+	// provider may not sign in to, such as an admin or a member of another
+	// institution.
+	ErrSSOLinkRefused = errors.New("an account with this email exists and cannot be linked to this SSO provider")
+)
+
+// OIDCLoginState is what the callback needs to finish a login. It is kept
+// by the client between the redirect to the provider and the callback.
+type OIDCLoginState struct {
+	Provider  string    `json:"provider"`
+	State     string    `json:"state"`
+	Nonce     string    `json:"nonce"`
+	Verifier  string    `json:"verifier"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type OIDCService interface {
+	Providers() []string
+	// AuthCodeURL starts a login, returning the provider URL to redirect to
+	// and the state to present again at the callback.
+	AuthCodeURL(ctx context.Context, provider string) (string, *OIDCLoginState, error)
+	// Login completes the authorization code flow and signs the user in,
+	// linking or provisioning an account on first login.
+	Login(ctx context.Context, code, state string, loginState *OIDCLoginState) (*response.LoginResponse, error)
+}
+
+type OIDCServiceImpl struct {
+	providers          map[string]*oidcProvider
+	names              []string
+	identityRepository repository.IdentityRepository
+	userService        UserService
+	authService        AuthService
+	config             config.OIDCConfig
+	logger             *slog.Logger
+}
+
+// oidcProvider discovers its endpoints on first use, so an unreachable
+// provider does not keep the server from starting.
+type oidcProvider struct {
+	config      config.OIDCProviderConfig
+	redirectURL string
+	mu          sync.Mutex
+	oauth2      *oauth2.Config
+	verifier    *oidc.IDTokenVerifier
+}
+
+func NewOIDCService(identityRepo repository.IdentityRepository, userService UserService, authService AuthService, oidcConfig config.OIDCConfig, logger *slog.Logger) OIDCService {
+	providers := make(map[string]*oidcProvider)
+	var names []string
+	base := strings.TrimSuffix(oidcConfig.RedirectBaseURL, "/")
+	for _, providerConfig := range oidcConfig.Providers {
+		providers[providerConfig.Name] = &oidcProvider{
+			config:      providerConfig,
+			redirectURL: base + "/api/auth/oidc/" + providerConfig.Name + "/callback",
+		}
+		names = append(names, providerConfig.Name)
+	}
+
+	return &OIDCServiceImpl{
+		providers:          providers,
+		names:              names,
+		identityRepository: identityRepo,
+		userService:        userService,
+		authService:        authService,
+		config:             oidcConfig,
+		logger:             logger,
+	}
+}
+
+func (s *OIDCServiceImpl) Providers() []string {
+	return s.names
+}
+
+func (s *OIDCServiceImpl) AuthCodeURL(ctx context.Context, provider string) (_ string, _ *OIDCLoginState, err error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.AuthCodeURL")
+	defer tracing.End(span, &err)
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", nil, ErrUnknownProvider
+	}
+	oauth2Config, _, err := p.client(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	state, err := utils.GenerateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	nonce, err := utils.GenerateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	loginState := &OIDCLoginState{
+		Provider:  provider,
+		State:     state,
+		Nonce:     nonce,
+		Verifier:  oauth2.GenerateVerifier(),
+		ExpiresAt: time.Now().Add(s.config.StateTTL),
+	}
+
+	authURL := oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(loginState.Verifier))
+	return authURL, loginState, nil
+}
+
+func (s *OIDCServiceImpl) Login(ctx context.Context, code, state string, loginState *OIDCLoginState) (_ *response.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.Login")
+	defer tracing.End(span, &err)
+
+	loginResponse, err := s.login(ctx, code, state, loginState)
+	result := metrics.ResultSuccess
+	if err != nil {
+		result = metrics.ResultFailure
+		s.logger.WarnContext(ctx, "SSO login failed", "error", err)
+	}
+	metrics.LoginAttempts.WithLabelValues(result).Inc()
+	return loginResponse, err
+}
+
+func (s *OIDCServiceImpl) login(ctx context.Context, code, state string, loginState *OIDCLoginState) (*response.LoginResponse, error) {
+	if loginState == nil || state == "" || loginState.State != state || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrInvalidSSOState
+	}
+	p, ok := s.providers[loginState.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	oauth2Config, verifier, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(loginState.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: exchanging code: %v", ErrSSOAuthentication, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: no ID token in response", ErrSSOAuthentication)
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOAuthentication, err)
+	}
+	if idToken.Nonce != loginState.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrSSOAuthentication)
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOAuthentication, err)
+	}
+
+	user, err := s.resolveUser(ctx, p.config, idToken.Subject, claims.Email, emailVerified(claims.EmailVerified), claims.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtToken, err := s.authService.GenerateTokenForUser(user)
+	if err != nil {
+		return nil, err
+	}
+	return &response.LoginResponse{
+		Message: "Login successful",
+		User:    user,
+		Token:   jwtToken,
+	}, nil
+}
+
+// resolveUser returns the user linked to the provider account. On first
+// login the account is linked to the user with the same verified email, or
+// a new user is provisioned.
+func (s *OIDCServiceImpl) resolveUser(ctx context.Context, provider config.OIDCProviderConfig, subject, email string, verified bool, name string) (*model.User, error) {
+	identity, err := s.identityRepository.FindByProviderAndSubject(ctx, provider.Name, subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.userService.GetUserByID(ctx, identity.UserID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			if err := s.identityRepository.TouchLastLogin(ctx, identity.ID); err != nil {
+				s.logger.WarnContext(ctx, "failed to update identity last login", "error", err)
+			}
+			return user, nil
+		}
+		// The user was deleted; drop the stale link and start over.
+		if err := s.identityRepository.DeleteByID(ctx, identity.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if email == "" || !verified {
+		return nil, ErrUnverifiedSSO
+	}
+	if provider.InstitutionID != "" {
+		ctx = tenant.WithInstitution(ctx, provider.InstitutionID)
+	}
+
+	user, err := s.userService.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		password, err := unusablePassword()
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = email[:strings.LastIndex(email, "@")]
+		}
+		created, err := s.userService.CreateUser(ctx, &request.CreateUserRequest{
+			Name:     name,
+			Email:    email,
+			Password: password,
+		})
+		if err != nil {
+			return nil, err
+		}
+		user = created.User
+		s.logger.InfoContext(ctx, "user provisioned via SSO", "user_id", user.ID.Hex(), "provider", provider.Name)
+	} else if !canAutoLink(provider, user) {
+		s.logger.WarnContext(ctx, "SSO identity not linked", "user_id", user.ID.Hex(), "provider", provider.Name, "role", user.Role)
+		return nil, ErrSSOLinkRefused
+	}
+
+	if _, err := s.identityRepository.Save(ctx, &model.Identity{
+		UserID:   user.ID,
+		Provider: provider.Name,
+		Subject:  subject,
+		Email:    email,
+	}); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "SSO identity linked", "user_id", user.ID.Hex(), "provider", provider.Name)
+	return user, nil
+}
+
+// canAutoLink reports whether provider may take over an existing account
+// on email alone. Admin accounts are never linked this way, and a provider
+// only reaches users of its own institution, or of none if it has none.
+func canAutoLink(provider config.OIDCProviderConfig, user *model.User) bool {
+	if user.Role == enums.Admin || user.Role == enums.InstitutionAdmin {
+		return false
+	}
+	return user.InstitutionID == provider.InstitutionID
+}
+
+func (p *oidcProvider) client(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	// Discovery must not be tied to the request that happens to trigger it.
+	provider, err := oidc.NewProvider(context.WithoutCancel(ctx), p.config.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("discovering SSO provider %s: %w", p.config.Name, err)
+	}
+
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+	return p.oauth2, p.verifier, nil
+}
+
+// emailVerified accepts both the boolean the spec requires and the string
+// some providers send instead.
+func emailVerified(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
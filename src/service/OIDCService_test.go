@@ -0,0 +1,360 @@
+package service
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/testutil"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "student-assistant"
+
+// mockProvider is a minimal OpenID Connect provider: discovery, JWKS and a
+// token endpoint that enforces PKCE and signs RS256 ID tokens.
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+// authorization is what the provider remembers between issuing a code and
+// exchanging it.
+type authorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	p := &mockProvider{key: key, codes: make(map[string]authorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize plays the user signing in at authURL and returns the code the
+// provider would redirect back with. The nonce from authURL is echoed in the
+// ID token unless claims override it.
+func (p *mockProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) string {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parsing auth URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+	}
+
+	idClaims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   testClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for name, value := range claims {
+		idClaims[name] = value
+	}
+
+	code, err := utils.GenerateToken()
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.codes[code] = authorization{challenge: query.Get("code_challenge"), claims: idClaims}
+	return code
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+type oidcFixture struct {
+	provider    *mockProvider
+	oidcService OIDCService
+	userService UserService
+	userRepo    repository.UserRepository
+	identities  *testutil.IdentityRepository
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+	provider := newMockProvider(t)
+	userRepo := repository.NewUserRepositoryMemory()
+	signupPolicy := NewSignupPolicy(&testutil.InstitutionRepository{}, config.SignupConfig{})
+	userService := NewUserServiceImpl(userRepo, &testutil.AuditRepository{}, signupPolicy, enums.User, testutil.TokenManager{}, testutil.DiscardLogger)
+	authService := NewAuthService(userService, testutil.TokenManager{}, testutil.DiscardLogger)
+	identities := &testutil.IdentityRepository{}
+	oidcService := NewOIDCService(identities, userService, authService, config.OIDCConfig{
+		RedirectBaseURL: "http://localhost:8080",
+		StateTTL:        time.Minute,
+		Providers: []config.OIDCProviderConfig{{
+			Name:         "campus",
+			Issuer:       provider.server.URL,
+			ClientID:     testClientID,
+			ClientSecret: "secret",
+		}},
+	}, testutil.DiscardLogger)
+
+	return &oidcFixture{
+		provider:    provider,
+		oidcService: oidcService,
+		userService: userService,
+		userRepo:    userRepo,
+		identities:  identities,
+	}
+}
+
+// login runs the whole flow, letting alter tamper with the callback before
+// it reaches the service.
+func (f *oidcFixture) login(t *testing.T, claims jwt.MapClaims, alter func(state *string, loginState *OIDCLoginState)) (string, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, loginState, err := f.oidcService.AuthCodeURL(ctx, "campus")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code := f.provider.authorize(t, authURL, claims)
+	state := loginState.State
+	if alter != nil {
+		alter(&state, loginState)
+	}
+
+	loginResponse, err := f.oidcService.Login(ctx, code, state, loginState)
+	if err != nil {
+		return "", err
+	}
+	return loginResponse.User.ID.Hex(), nil
+}
+
+func TestOIDCLoginProvisionsAndLinks(t *testing.T) {
+	fixture := newOIDCFixture(t)
+	claims := jwt.MapClaims{"sub": "alice-1", "email": "Alice@Example.com", "email_verified": true, "name": "Alice"}
+
+	userID, err := fixture.login(t, claims, nil)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	user, err := fixture.userService.GetUserByID(context.Background(), userID)
+	if err != nil || user == nil {
+		t.Fatalf("provisioned user not found: %v", err)
+	}
+	if user.Email != "alice@example.com" || user.Name != "Alice" || user.Role != enums.User {
+		t.Errorf("provisioned user = %s <%s> %s", user.Name, user.Email, user.Role)
+	}
+
+	// A later login finds the linked identity even if the email changed.
+	claims["email"] = "alice@elsewhere.com"
+	again, err := fixture.login(t, claims, nil)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again != userID {
+		t.Errorf("second login signed in %s, want %s", again, userID)
+	}
+	if fixture.identities.Len() != 1 {
+		t.Errorf("identities = %d, want 1", fixture.identities.Len())
+	}
+}
+
+func TestOIDCLoginLinksExistingUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		verified      any
+		role          enums.Role
+		institutionID string
+		wantErr       error
+	}{
+		{name: "verified email", verified: true},
+		{name: "verified as a string", verified: "true"},
+		{name: "unverified email", verified: false, wantErr: ErrUnverifiedSSO},
+		{name: "no verification claim", wantErr: ErrUnverifiedSSO},
+		{name: "admin account", verified: true, role: enums.Admin, wantErr: ErrSSOLinkRefused},
+		{name: "institution admin account", verified: true, role: enums.InstitutionAdmin, institutionID: "uni-a", wantErr: ErrSSOLinkRefused},
+		{name: "member of another institution", verified: true, institutionID: "uni-a", wantErr: ErrSSOLinkRefused},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newOIDCFixture(t)
+			role := test.role
+			if role == "" {
+				role = enums.User
+			}
+			existing, err := fixture.userRepo.Save(context.Background(), &model.User{Name: "Bob", Email: "bob@example.com", Role: role, InstitutionID: test.institutionID})
+			if err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+
+			claims := jwt.MapClaims{"sub": "bob-1", "email": "bob@example.com"}
+			if test.verified != nil {
+				claims["email_verified"] = test.verified
+			}
+			userID, err := fixture.login(t, claims, nil)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Login error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr == nil && userID != existing.ID.Hex() {
+				t.Errorf("signed in %s, want the existing user %s", userID, existing.ID.Hex())
+			}
+			if test.wantErr != nil && fixture.identities.Len() != 0 {
+				t.Error("a rejected login linked an identity")
+			}
+		})
+	}
+}
+
+func TestOIDCLoginRejects(t *testing.T) {
+	claims := jwt.MapClaims{"sub": "carol-1", "email": "carol@example.com", "email_verified": true}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		alter   func(state *string, loginState *OIDCLoginState)
+		wantErr error
+	}{
+		{
+			name:    "state mismatch",
+			alter:   func(state *string, loginState *OIDCLoginState) { *state = "forged" },
+			wantErr: ErrInvalidSSOState,
+		},
+		{
+			name:    "expired state",
+			alter:   func(state *string, loginState *OIDCLoginState) { loginState.ExpiresAt = time.Now().Add(-time.Second) },
+			wantErr: ErrInvalidSSOState,
+		},
+		{
+			name:    "unknown provider in state",
+			alter:   func(state *string, loginState *OIDCLoginState) { loginState.Provider = "elsewhere" },
+			wantErr: ErrUnknownProvider,
+		},
+		{
+			name:    "nonce mismatch",
+			claims:  jwt.MapClaims{"nonce": "replayed"},
+			wantErr: ErrSSOAuthentication,
+		},
+		{
+			name:    "wrong verifier",
+			alter:   func(state *string, loginState *OIDCLoginState) { loginState.Verifier = "not-the-verifier" },
+			wantErr: ErrSSOAuthentication,
+		},
+		{
+			name:    "token for another client",
+			claims:  jwt.MapClaims{"aud": "someone-else"},
+			wantErr: ErrSSOAuthentication,
+		},
+		{
+			name:    "expired token",
+			claims:  jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()},
+			wantErr: ErrSSOAuthentication,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newOIDCFixture(t)
+			merged := jwt.MapClaims{}
+			for name, value := range claims {
+				merged[name] = value
+			}
+			for name, value := range test.claims {
+				merged[name] = value
+			}
+
+			_, err := fixture.login(t, merged, test.alter)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Login error = %v, want %v", err, test.wantErr)
+			}
+			if fixture.identities.Len() != 0 {
+				t.Error("a rejected login linked an identity")
+			}
+		})
+	}
+}
+
+func TestOIDCUnknownProvider(t *testing.T) {
+	fixture := newOIDCFixture(t)
+	if _, _, err := fixture.oidcService.AuthCodeURL(context.Background(), "elsewhere"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("AuthCodeURL error = %v, want %v", err, ErrUnknownProvider)
+	}
+	if _, err := fixture.oidcService.Login(context.Background(), "code", "state", nil); !errors.Is(err, ErrInvalidSSOState) {
+		t.Fatalf("Login without state error = %v, want %v", err, ErrInvalidSSOState)
+	}
+}
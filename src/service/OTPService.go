@@ -1,58 +1,104 @@
 package service
 
 import (
+	"Student-Assistant-App/src/clock"
+	"Student-Assistant-App/src/config"
 	"Student-Assistant-App/src/data/model"
 	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/metrics"
+	"Student-Assistant-App/src/tracing"
+	"Student-Assistant-App/src/utils"
 	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/big"
 	"time"
 )
 
+// ErrOTPNotResendable is returned when a client asks to resend a code it
+// could not have requested itself, such as an invitation's set-password
+// code.
+var ErrOTPNotResendable = errors.New("codes for this purpose cannot be resent")
+
 type OTPService interface {
 	GenerateAndSendOTP(ctx context.Context, email, purpose string) error
 	VerifyOTP(ctx context.Context, email, code, purpose string) error
+	// ResendOTP replaces the code for a purpose users request themselves:
+	// signup, login or password_reset.
 	ResendOTP(ctx context.Context, email, purpose string) error
+	// PurgeOTPs deletes expired codes, or every code for email when one is
+	// given, and returns how many were deleted.
+	PurgeOTPs(ctx context.Context, email string) (int64, error)
 }
 
 type OTPServiceImpl struct {
 	otpRepository repository.OTPRepository
 	emailService  EmailService
+	config        config.OTPConfig
+	clock         clock.Clock
+	logger        *slog.Logger
 }
 
-func NewOTPService(otpRepo repository.OTPRepository, emailService EmailService) OTPService {
+func NewOTPService(otpRepo repository.OTPRepository, emailService EmailService, otpConfig config.OTPConfig, clock clock.Clock, logger *slog.Logger) OTPService {
 	return &OTPServiceImpl{
 		otpRepository: otpRepo,
 		emailService:  emailService,
+		config:        otpConfig,
+		clock:         clock,
+		logger:        logger,
 	}
 }
 
-func (s *OTPServiceImpl) GenerateAndSendOTP(ctx context.Context, email, purpose string) error {
+func (s *OTPServiceImpl) GenerateAndSendOTP(ctx context.Context, email, purpose string) (err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.GenerateAndSendOTP")
+	defer tracing.End(span, &err)
+
 	otpCode, err := s.generateOTP()
 	if err != nil {
 		return err
 	}
 
+	ttl := s.ttl(purpose)
 	otp := &model.OTP{
 		Email:     email,
-		Code:      otpCode,
+		CodeHash:  utils.HashOTP(otpCode, s.config.Secret),
 		Purpose:   purpose,
-		ExpiresAt: time.Now().Add(2 * time.Minute), 
+		ExpiresAt: s.clock.Now().Add(ttl),
 		Used:      false,
 	}
 
-	_, err = s.otpRepository.Save(ctx, otp)
+	_, err = s.otpRepository.Supersede(ctx, otp)
 	if err != nil {
 		return err
 	}
 
-	return s.emailService.SendOTP(email, otpCode, purpose)
+	if err := s.emailService.SendOTP(ctx, email, otpCode, purpose, ttl); err != nil {
+		return err
+	}
+
+	metrics.OTPEvents.WithLabelValues(purposeLabel(purpose), metrics.OTPIssued).Inc()
+	s.logger.InfoContext(ctx, "OTP issued", "email", email, "purpose", purpose)
+	return nil
 }
 
-func (s *OTPServiceImpl) VerifyOTP(ctx context.Context, email, code, purpose string) error {
-	otp, err := s.otpRepository.FindByEmailAndCode(ctx, email, code)
+func (s *OTPServiceImpl) VerifyOTP(ctx context.Context, email, code, purpose string) (err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.VerifyOTP")
+	defer tracing.End(span, &err)
+
+	err = s.verifyOTP(ctx, email, code, purpose)
+	event := metrics.OTPVerified
+	if err != nil {
+		event = metrics.OTPFailed
+		s.logger.WarnContext(ctx, "OTP verification failed", "email", email, "purpose", purpose, "error", err)
+	}
+	metrics.OTPEvents.WithLabelValues(purposeLabel(purpose), event).Inc()
+	return err
+}
+
+func (s *OTPServiceImpl) verifyOTP(ctx context.Context, email, code, purpose string) error {
+	otp, err := s.otpRepository.FindActiveByEmailAndPurpose(ctx, email, purpose)
 	if err != nil {
 		return err
 	}
@@ -61,38 +107,85 @@ func (s *OTPServiceImpl) VerifyOTP(ctx context.Context, email, code, purpose str
 		return errors.New("invalid or expired OTP")
 	}
 
-	if otp.Purpose != purpose {
-		return errors.New("OTP purpose mismatch")
+	if !otp.IsValid(s.clock) {
+		return errors.New("invalid or expired OTP")
 	}
 
-	if !otp.IsValid() {
+	// Count the try before comparing so concurrent guesses cannot exceed
+	// the limit.
+	claimed, err := s.otpRepository.ClaimAttempt(ctx, otp.ID, s.config.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !claimed || !utils.CheckOTP(code, otp.CodeHash, s.config.Secret) {
 		return errors.New("invalid or expired OTP")
 	}
 
 	return s.otpRepository.MarkAsUsed(ctx, otp.ID)
 }
 
-func (s *OTPServiceImpl) ResendOTP(ctx context.Context, email, purpose string) error {
+func (s *OTPServiceImpl) ResendOTP(ctx context.Context, email, purpose string) (err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.ResendOTP")
+	defer tracing.End(span, &err)
+
+	// Anyone who knows an address may resend, so this must not supersede
+	// a code an admin issued.
+	switch purpose {
+	case "signup", "login", "password_reset":
+	default:
+		return ErrOTPNotResendable
+	}
+
 	latestOTP, err := s.otpRepository.FindLatestByEmailAndPurpose(ctx, email, purpose)
 	if err != nil {
 		return err
 	}
 
-	if latestOTP != nil && time.Since(latestOTP.CreatedAt) < time.Minute {
+	if latestOTP != nil && s.clock.Now().Sub(latestOTP.CreatedAt) < s.config.ResendInterval {
 		return errors.New("please wait before requesting a new OTP")
 	}
 
 	return s.GenerateAndSendOTP(ctx, email, purpose)
 }
 
+func (s *OTPServiceImpl) PurgeOTPs(ctx context.Context, email string) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "OTPService.PurgeOTPs")
+	defer tracing.End(span, &err)
+
+	if email != "" {
+		return s.otpRepository.DeleteByEmail(ctx, email)
+	}
+	return s.otpRepository.DeleteExpired(ctx)
+}
+
+// ttl returns how long a code for purpose stays valid. Set-password codes
+// go to users who did not ask for them, so they get longer to respond.
+func (s *OTPServiceImpl) ttl(purpose string) time.Duration {
+	if purpose == "set_password" {
+		return s.config.InviteTTL
+	}
+	return s.config.TTL
+}
+
 func (s *OTPServiceImpl) generateOTP() (string, error) {
 	max := big.NewInt(999999)
 	min := big.NewInt(100000)
-	
+
 	n, err := rand.Int(rand.Reader, max.Sub(max, min).Add(max, big.NewInt(1)))
 	if err != nil {
 		return "", err
 	}
-	
+
 	return fmt.Sprintf("%06d", n.Add(n, min).Int64()), nil
-}
\ No newline at end of file
+}
+
+// purposeLabel bounds the metric label to known purposes, since the purpose
+// on verify and resend requests comes straight from the client.
+func purposeLabel(purpose string) string {
+	switch purpose {
+	case "signup", "login", "password_reset", "set_password":
+		return purpose
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"Student-Assistant-App/src/clock"
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/testutil"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestOTPService() (OTPService, *testutil.EmailService, *clock.Fake) {
+	otpConfig := config.Default().OTP
+	otpConfig.Secret = "otp-secret"
+	fakeClock := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	emailService := testutil.NewEmailService()
+	otpService := NewOTPService(repository.NewOTPRepositoryMemory(fakeClock), emailService, otpConfig, fakeClock, testutil.DiscardLogger)
+	return otpService, emailService, fakeClock
+}
+
+func TestVerifyOTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose string
+		// act runs between sending and verifying a code, and may replace it.
+		act     func(t *testing.T, ctx context.Context, otpService OTPService, fakeClock *clock.Fake, code string) string
+		wantErr bool
+	}{
+		{
+			name:    "valid code",
+			purpose: "login",
+			act:     func(_ *testing.T, _ context.Context, _ OTPService, _ *clock.Fake, code string) string { return code },
+		},
+		{
+			name:    "wrong code",
+			purpose: "login",
+			act: func(_ *testing.T, _ context.Context, _ OTPService, _ *clock.Fake, code string) string {
+				if code == "000000" {
+					return "111111"
+				}
+				return "000000"
+			},
+			wantErr: true,
+		},
+		{
+			name:    "just before expiry",
+			purpose: "signup",
+			act: func(_ *testing.T, _ context.Context, _ OTPService, fakeClock *clock.Fake, code string) string {
+				fakeClock.Advance(2*time.Minute - time.Second)
+				return code
+			},
+		},
+		{
+			name:    "expired",
+			purpose: "signup",
+			act: func(_ *testing.T, _ context.Context, _ OTPService, fakeClock *clock.Fake, code string) string {
+				fakeClock.Advance(2*time.Minute + time.Second)
+				return code
+			},
+			wantErr: true,
+		},
+		{
+			name:    "set-password codes last longer",
+			purpose: "set_password",
+			act: func(_ *testing.T, _ context.Context, _ OTPService, fakeClock *clock.Fake, code string) string {
+				fakeClock.Advance(71 * time.Hour)
+				return code
+			},
+		},
+		{
+			name:    "too many wrong codes",
+			purpose: "set_password",
+			act: func(t *testing.T, ctx context.Context, otpService OTPService, _ *clock.Fake, code string) string {
+				wrong := "000000"
+				if code == wrong {
+					wrong = "111111"
+				}
+				for range 5 {
+					if err := otpService.VerifyOTP(ctx, "a@uni.edu", wrong, "set_password"); err == nil {
+						t.Fatal("VerifyOTP accepted a wrong code")
+					}
+				}
+				return code
+			},
+			wantErr: true,
+		},
+		{
+			name:    "already used",
+			purpose: "login",
+			act: func(t *testing.T, ctx context.Context, otpService OTPService, _ *clock.Fake, code string) string {
+				if err := otpService.VerifyOTP(ctx, "a@uni.edu", code, "login"); err != nil {
+					t.Fatalf("VerifyOTP: %v", err)
+				}
+				return code
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			otpService, emailService, fakeClock := newTestOTPService()
+			if err := otpService.GenerateAndSendOTP(ctx, "a@uni.edu", test.purpose); err != nil {
+				t.Fatalf("GenerateAndSendOTP: %v", err)
+			}
+			code := test.act(t, ctx, otpService, fakeClock, emailService.LastCode("a@uni.edu", test.purpose))
+
+			err := otpService.VerifyOTP(ctx, "a@uni.edu", code, test.purpose)
+			if (err != nil) != test.wantErr {
+				t.Errorf("VerifyOTP error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyOTPChecksPurposeAndEmail(t *testing.T) {
+	ctx := context.Background()
+	otpService, emailService, _ := newTestOTPService()
+	if err := otpService.GenerateAndSendOTP(ctx, "a@uni.edu", "login"); err != nil {
+		t.Fatalf("GenerateAndSendOTP: %v", err)
+	}
+	code := emailService.LastCode("a@uni.edu", "login")
+
+	if err := otpService.VerifyOTP(ctx, "a@uni.edu", code, "password_reset"); err == nil {
+		t.Error("a login code verified for password_reset")
+	}
+	if err := otpService.VerifyOTP(ctx, "b@uni.edu", code, "login"); err == nil {
+		t.Error("a code verified for another email")
+	}
+	if err := otpService.VerifyOTP(ctx, "A@Uni.EDU", code, "login"); err != nil {
+		t.Errorf("VerifyOTP with a differently cased email: %v", err)
+	}
+}
+
+func TestResendOTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		wait    time.Duration
+		wantErr bool
+	}{
+		{"too soon", 30 * time.Second, true},
+		{"after the resend interval", time.Minute, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			otpService, emailService, fakeClock := newTestOTPService()
+			if err := otpService.GenerateAndSendOTP(ctx, "a@uni.edu", "login"); err != nil {
+				t.Fatalf("GenerateAndSendOTP: %v", err)
+			}
+			first := emailService.LastCode("a@uni.edu", "login")
+
+			fakeClock.Advance(test.wait)
+			err := otpService.ResendOTP(ctx, "a@uni.edu", "login")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ResendOTP error = %v, want error %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			second := emailService.LastCode("a@uni.edu", "login")
+			if first != second {
+				if err := otpService.VerifyOTP(ctx, "a@uni.edu", first, "login"); err == nil {
+					t.Error("the superseded code still verifies")
+				}
+			}
+			if err := otpService.VerifyOTP(ctx, "a@uni.edu", second, "login"); err != nil {
+				t.Errorf("VerifyOTP with the new code: %v", err)
+			}
+		})
+	}
+}
+
+func TestResendOTPRefusesInvitationCodes(t *testing.T) {
+	ctx := context.Background()
+	otpService, emailService, fakeClock := newTestOTPService()
+	if err := otpService.GenerateAndSendOTP(ctx, "a@uni.edu", "set_password"); err != nil {
+		t.Fatalf("GenerateAndSendOTP: %v", err)
+	}
+	invitation := emailService.LastCode("a@uni.edu", "set_password")
+
+	fakeClock.Advance(time.Hour)
+	for _, purpose := range []string{"set_password", "other"} {
+		if err := otpService.ResendOTP(ctx, "a@uni.edu", purpose); !errors.Is(err, ErrOTPNotResendable) {
+			t.Errorf("ResendOTP(%q) error = %v, want %v", purpose, err, ErrOTPNotResendable)
+		}
+	}
+	if err := otpService.VerifyOTP(ctx, "a@uni.edu", invitation, "set_password"); err != nil {
+		t.Errorf("the invitation code no longer verifies: %v", err)
+	}
+}
+
+func TestGenerateAndSendOTPEmailFailure(t *testing.T) {
+	otpService, emailService, _ := newTestOTPService()
+	emailService.Fail(errors.New("smtp down"))
+
+	if err := otpService.GenerateAndSendOTP(context.Background(), "a@uni.edu", "login"); err == nil {
+		t.Error("GenerateAndSendOTP succeeded although the email failed")
+	}
+}
+
+func TestPurgeOTPs(t *testing.T) {
+	ctx := context.Background()
+	otpService, _, fakeClock := newTestOTPService()
+	for _, email := range []string{"a@uni.edu", "b@uni.edu"} {
+		if err := otpService.GenerateAndSendOTP(ctx, email, "login"); err != nil {
+			t.Fatalf("GenerateAndSendOTP: %v", err)
+		}
+	}
+	if err := otpService.GenerateAndSendOTP(ctx, "a@uni.edu", "set_password"); err != nil {
+		t.Fatalf("GenerateAndSendOTP: %v", err)
+	}
+
+	if deleted, err := otpService.PurgeOTPs(ctx, ""); err != nil || deleted != 0 {
+		t.Errorf("PurgeOTPs before expiry = %d, %v; want 0", deleted, err)
+	}
+	fakeClock.Advance(time.Hour)
+	if deleted, err := otpService.PurgeOTPs(ctx, ""); err != nil || deleted != 2 {
+		t.Errorf("PurgeOTPs after login codes expired = %d, %v; want 2", deleted, err)
+	}
+	if deleted, err := otpService.PurgeOTPs(ctx, "a@uni.edu"); err != nil || deleted != 1 {
+		t.Errorf("PurgeOTPs for a@uni.edu = %d, %v; want 1", deleted, err)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/tenant"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrEmailDomainNotAllowed = errors.New("email domain is not allowed to sign up")
+	ErrDisposableEmail       = errors.New("disposable email addresses are not allowed")
+)
+
+// defaultDisposableDomains covers the most common throwaway-mail providers;
+// deployments can extend it through SignupConfig.DisposableDomains.
+var defaultDisposableDomains = []string{
+	"10minutemail.com",
+	"dispostable.com",
+	"fakeinbox.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"mailinator.com",
+	"maildrop.cc",
+	"sharklasers.com",
+	"temp-mail.org",
+	"tempmail.com",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+// SignupPolicy decides which email addresses may create accounts and which
+// institution they belong to.
+type SignupPolicy interface {
+	// Check returns the ID of the institution owning the email's domain, or
+	// "" when no institutions exist. When ctx is scoped to an institution the
+	// email must belong to that institution.
+	Check(ctx context.Context, email string) (string, error)
+}
+
+type SignupPolicyImpl struct {
+	institutionRepository repository.InstitutionRepository
+	disposable            map[string]bool
+	blockDisposable       bool
+}
+
+func NewSignupPolicy(institutionRepo repository.InstitutionRepository, signupConfig config.SignupConfig) SignupPolicy {
+	disposable := make(map[string]bool)
+	for _, domain := range append(defaultDisposableDomains, signupConfig.DisposableDomains...) {
+		disposable[utils.NormalizeDomain(domain)] = true
+	}
+
+	return &SignupPolicyImpl{
+		institutionRepository: institutionRepo,
+		disposable:            disposable,
+		blockDisposable:       signupConfig.BlockDisposable,
+	}
+}
+
+func (p *SignupPolicyImpl) Check(ctx context.Context, email string) (string, error) {
+	normalized, err := utils.EmailVerification(email)
+	if err != nil {
+		return "", err
+	}
+	domain := normalized[strings.LastIndex(normalized, "@")+1:]
+
+	if p.blockDisposable && matchDomain(domain, p.disposable) != "" {
+		return "", ErrDisposableEmail
+	}
+
+	count, err := p.institutionRepository.Count(ctx)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", nil
+	}
+
+	candidates := parentDomains(domain)
+	institutions, err := p.institutionRepository.FindByDomains(ctx, candidates)
+	if err != nil {
+		return "", err
+	}
+	owners := make(map[string]string)
+	for _, institution := range institutions {
+		for _, owned := range institution.Domains {
+			owners[owned] = institution.ID
+		}
+	}
+
+	parent := matchDomain(domain, owners)
+	if parent == "" {
+		return "", ErrEmailDomainNotAllowed
+	}
+	if scoped, ok := tenant.InstitutionFromContext(ctx); ok && scoped != owners[parent] {
+		return "", ErrEmailDomainNotAllowed
+	}
+	return owners[parent], nil
+}
+
+// parentDomains returns domain followed by each of its parent domains.
+func parentDomains(domain string) []string {
+	domains := []string{domain}
+	for {
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return domains
+		}
+		domain = domain[dot+1:]
+		domains = append(domains, domain)
+	}
+}
+
+// matchDomain returns the entry of domains that equals domain or is one of
+// its parent domains, preferring the most specific match.
+func matchDomain[V any](domain string, domains map[string]V) string {
+	for {
+		if _, ok := domains[domain]; ok {
+			return domain
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return ""
+		}
+		domain = domain[dot+1:]
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/dtos/response"
+	"Student-Assistant-App/src/tracing"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// MaxImportRows bounds a single import so one request cannot tie up the
+// database or mail server for long.
+const MaxImportRows = 5000
+
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionError  = "error"
+)
+
+var ErrTooManyImportRows = fmt.Errorf("imports are limited to %d users", MaxImportRows)
+
+// ImportUsers creates users, or renames existing ones, by email. Each row is
+// validated and applied on its own, so one bad row does not abort the
+// import; its error is reported in the result instead. With dryRun nothing
+// is written.
+func (userService *UserServiceImpl) ImportUsers(ctx context.Context, rows []request.ImportUserRow, dryRun bool) (_ *response.ImportUsersResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.ImportUsers")
+	defer tracing.End(span, &err)
+
+	if len(rows) > MaxImportRows {
+		return nil, ErrTooManyImportRows
+	}
+
+	result := &response.ImportUsersResponse{DryRun: dryRun}
+	seen := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		rowResult := response.ImportUserResult{Row: i + 1, Email: row.Email}
+
+		action, err := userService.importUser(ctx, row, seen, i+1, dryRun)
+		if err != nil {
+			rowResult.Action = ImportActionError
+			rowResult.Error = err.Error()
+			result.Failed++
+		} else {
+			rowResult.Action = action
+			rowResult.Email = row.Email
+			if action == ImportActionCreate {
+				result.Created++
+			} else {
+				result.Updated++
+			}
+		}
+		result.Rows = append(result.Rows, rowResult)
+	}
+
+	if !dryRun {
+		userService.logger.InfoContext(ctx, "users imported",
+			"created", result.Created, "updated", result.Updated, "failed", result.Failed)
+	}
+	return result, nil
+}
+
+// importUser validates and applies one row, normalising row.Email in place.
+// It returns whether the row creates or updates a user.
+func (userService *UserServiceImpl) importUser(ctx context.Context, row *request.ImportUserRow, seen map[string]int, rowNumber int, dryRun bool) (string, error) {
+	email, err := utils.EmailVerification(row.Email)
+	if err != nil {
+		return "", err
+	}
+	row.Email = email
+	if previous, ok := seen[email]; ok {
+		return "", fmt.Errorf("email repeats row %d", previous)
+	}
+	seen[email] = rowNumber
+
+	if row.Role != "" && !row.Role.IsValid() {
+		return "", fmt.Errorf("invalid role %q", row.Role)
+	}
+	if row.Role == enums.Admin {
+		return "", errors.New("global admins cannot be imported")
+	}
+
+	user, err := userService.userRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return "", err
+	}
+
+	action := ImportActionUpdate
+	if user != nil {
+		// Role changes are audited, and only the owner sets a password.
+		if row.Role != "" && row.Role != user.Role {
+			return "", errors.New("roles of existing users can only be changed through the role endpoint")
+		}
+		if row.Password != "" {
+			return "", errors.New("passwords of existing users cannot be imported")
+		}
+	} else {
+		if row.Name == "" {
+			return "", errors.New("name is required")
+		}
+		institutionID, err := userService.signupPolicy.Check(ctx, email)
+		if err != nil {
+			return "", err
+		}
+		action = ImportActionCreate
+		user = &model.User{Email: email, Role: userService.defaultRole, InstitutionID: institutionID}
+	}
+
+	if row.Name != "" {
+		user.Name = row.Name
+	}
+	if row.Role != "" {
+		user.Role = row.Role
+	}
+	if user.Role == enums.InstitutionAdmin && user.InstitutionID == "" {
+		return "", ErrInstitutionAdminScope
+	}
+	if dryRun {
+		return action, nil
+	}
+
+	if action == ImportActionCreate {
+		password := row.Password
+		if password == "" {
+			// Imported users without a password cannot sign in until they
+			// set one with an invitation code.
+			if password, err = unusablePassword(); err != nil {
+				return "", err
+			}
+		}
+		if user.Password, err = utils.HashPassword(password); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := userService.userRepository.Save(ctx, user); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+func unusablePassword() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -1,36 +1,132 @@
 package service
 
 import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
 	"Student-Assistant-App/src/data/model"
 	"Student-Assistant-App/src/data/repository"
 	"Student-Assistant-App/src/dtos/request"
 	"Student-Assistant-App/src/dtos/response"
 	"Student-Assistant-App/src/mapper"
+	"Student-Assistant-App/src/tokens"
+	"Student-Assistant-App/src/tracing"
 	"Student-Assistant-App/src/utils"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 )
 
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrRoleChangeDenied = errors.New("not allowed to assign this role")
+	ErrOwnRoleChange    = errors.New("admins cannot change their own role")
+	// ErrInstitutionChange is returned when an institution admin's new email
+	// belongs to another institution.
+	ErrInstitutionChange = errors.New("institution admins cannot move to another institution")
+)
+
+// Actor identifies the authenticated user performing an admin action.
+type Actor struct {
+	UserID string
+	Role   enums.Role
+}
+
 type UserService interface {
+	// CreateUser signs up a user with the configured default role.
 	CreateUser(ctx context.Context, request *request.CreateUserRequest) (*response.CreateUserResponse, error)
+	// CreateUserWithRole is for trusted internal paths, such as accepting an
+	// invitation, that have already decided the role. The user is created
+	// as by CreateAccount and signed in.
+	CreateUserWithRole(ctx context.Context, request *request.CreateUserRequest, role enums.Role) (*response.CreateUserResponse, error)
+	// CreateAccount creates a user with role for operator tooling. Admins
+	// are created as by CreateAdmin. No token is issued, so it works before
+	// any signing key exists.
+	CreateAccount(ctx context.Context, request *request.CreateUserRequest, role enums.Role) (*model.User, error)
+	// CreateAdmin creates a global admin. Global admins belong to no
+	// institution, so the signup policy does not apply. No token is issued,
+	// so it works before any signing key exists.
+	CreateAdmin(ctx context.Context, request *request.CreateUserRequest) (*model.User, error)
 	GetUserByID(ctx context.Context, id string) (*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 	GetAllUsers(ctx context.Context) ([]*model.User, error)
+	FindUsers(ctx context.Context, filter repository.UserFilter) ([]*model.User, error)
 	UpdateUser(ctx context.Context, id string, request *request.UpdateUserRequest) (*model.User, error)
 	DeleteUser(ctx context.Context, id string) error
+	FindDuplicateEmails(ctx context.Context) ([]repository.DuplicateEmail, error)
+	CheckSignupEmail(ctx context.Context, email string) error
+	SetPassword(ctx context.Context, email, password string) error
+	ImportUsers(ctx context.Context, rows []request.ImportUserRow, dryRun bool) (*response.ImportUsersResponse, error)
+	SetUserRole(ctx context.Context, actor Actor, id string, role enums.Role) (*model.User, error)
+	BootstrapAdmin(ctx context.Context, bootstrapConfig config.BootstrapConfig) error
 }
 
 type UserServiceImpl struct {
-	userRepository repository.UserRepository
+	userRepository  repository.UserRepository
+	auditRepository repository.AuditRepository
+	signupPolicy    SignupPolicy
+	defaultRole     enums.Role
+	tokenManager    tokens.Manager
+	logger          *slog.Logger
 }
 
-func NewUserServiceImpl(userRepo repository.UserRepository) UserService {
+func NewUserServiceImpl(userRepo repository.UserRepository, auditRepo repository.AuditRepository, signupPolicy SignupPolicy, defaultRole enums.Role, tokenManager tokens.Manager, logger *slog.Logger) UserService {
 	return &UserServiceImpl{
-		userRepository: userRepo,
+		userRepository:  userRepo,
+		auditRepository: auditRepo,
+		signupPolicy:    signupPolicy,
+		defaultRole:     defaultRole,
+		tokenManager:    tokenManager,
+		logger:          logger,
+	}
+}
+
+func (userService *UserServiceImpl) CreateUser(ctx context.Context, request *request.CreateUserRequest) (_ *response.CreateUserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer tracing.End(span, &err)
+
+	user, err := userService.createUser(ctx, request, userService.defaultRole, true)
+	if err != nil {
+		return nil, err
+	}
+	return userService.signedUp(user)
+}
+
+func (userService *UserServiceImpl) CreateUserWithRole(ctx context.Context, request *request.CreateUserRequest, role enums.Role) (_ *response.CreateUserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUserWithRole")
+	defer tracing.End(span, &err)
+
+	user, err := userService.CreateAccount(ctx, request, role)
+	if err != nil {
+		return nil, err
+	}
+	return userService.signedUp(user)
+}
+
+func (userService *UserServiceImpl) CreateAccount(ctx context.Context, request *request.CreateUserRequest, role enums.Role) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateAccount")
+	defer tracing.End(span, &err)
+
+	if !role.IsValid() {
+		return nil, fmt.Errorf("invalid role %q", role)
+	}
+	if role == enums.Admin {
+		return userService.CreateAdmin(ctx, request)
 	}
+	return userService.createUser(ctx, request, role, true)
 }
 
-func (userService *UserServiceImpl) CreateUser(ctx context.Context, request *request.CreateUserRequest) (*response.CreateUserResponse, error) {
+func (userService *UserServiceImpl) CreateAdmin(ctx context.Context, request *request.CreateUserRequest) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateAdmin")
+	defer tracing.End(span, &err)
+
+	return userService.createUser(ctx, request, enums.Admin, false)
+}
+
+// createUser stores a new account. With checkPolicy the email must pass the
+// signup policy, which also picks the user's institution.
+func (userService *UserServiceImpl) createUser(ctx context.Context, request *request.CreateUserRequest, role enums.Role, checkPolicy bool) (*model.User, error) {
+
 	if request.Email == "" {
 		return nil, errors.New("email is required")
 	}
@@ -55,8 +151,19 @@ func (userService *UserServiceImpl) CreateUser(ctx context.Context, request *req
 	}
 
 	user.Name = request.Name
+	user.Role = role
+
+	if checkPolicy {
+		institutionID, err := userService.signupPolicy.Check(ctx, user.Email)
+		if err != nil {
+			return nil, err
+		}
+		user.InstitutionID = institutionID
+	}
 
+	_, hashSpan := tracing.Start(ctx, "bcrypt.HashPassword")
 	hashedPassword, err := utils.HashPassword(user.Password)
+	hashSpan.End()
 	if err != nil {
 		return nil, err
 	}
@@ -67,38 +174,61 @@ func (userService *UserServiceImpl) CreateUser(ctx context.Context, request *req
 		return nil, err
 	}
 
-	token, err := utils.GenerateJWT(savedUser.ID.Hex(), savedUser.Email, savedUser.Role)
+	userService.logger.InfoContext(ctx, "user created", "user_id", savedUser.ID.Hex(), "email", savedUser.Email, "role", savedUser.Role)
+	return savedUser, nil
+}
+
+// signedUp issues the token a newly created user is signed in with.
+func (userService *UserServiceImpl) signedUp(user *model.User) (*response.CreateUserResponse, error) {
+	token, err := userService.tokenManager.Issue(user.ID.Hex(), user.Email, user.Role, user.InstitutionID)
 	if err != nil {
 		return nil, err
 	}
-
-	response := &response.CreateUserResponse{
-		User:    savedUser,
+	return &response.CreateUserResponse{
+		User:    user,
 		Message: "User created successfully",
 		Token:   token,
-	}
-	return response, nil
+	}, nil
 }
 
-func (userService *UserServiceImpl) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+func (userService *UserServiceImpl) GetUserByID(ctx context.Context, id string) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer tracing.End(span, &err)
+
 	return userService.userRepository.FindByID(ctx, id)
 }
 
-func (userService *UserServiceImpl) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+func (userService *UserServiceImpl) GetUserByEmail(ctx context.Context, email string) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer tracing.End(span, &err)
+
 	return userService.userRepository.FindByEmail(ctx, email)
 }
 
-func (userService *UserServiceImpl) GetAllUsers(ctx context.Context) ([]*model.User, error) {
+func (userService *UserServiceImpl) GetAllUsers(ctx context.Context) (_ []*model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer tracing.End(span, &err)
+
 	return userService.userRepository.FindAll(ctx)
 }
 
-func (userService *UserServiceImpl) UpdateUser(ctx context.Context, id string, request *request.UpdateUserRequest) (*model.User, error) {
+func (userService *UserServiceImpl) FindUsers(ctx context.Context, filter repository.UserFilter) (_ []*model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindUsers")
+	defer tracing.End(span, &err)
+
+	return userService.userRepository.FindByFilter(ctx, filter)
+}
+
+func (userService *UserServiceImpl) UpdateUser(ctx context.Context, id string, request *request.UpdateUserRequest) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer tracing.End(span, &err)
+
 	existingUser, err := userService.userRepository.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if existingUser == nil {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	if request.Name != "" {
@@ -116,23 +246,185 @@ func (userService *UserServiceImpl) UpdateUser(ctx context.Context, id string, r
 		if userWithEmail != nil && userWithEmail.ID != existingUser.ID {
 			return nil, errors.New("email already taken by another user")
 		}
+		if validEmail != existingUser.Email && existingUser.Role != enums.Admin {
+			// A new address must be one the user could have signed up
+			// with, and moves them to the institution that owns it.
+			institutionID, err := userService.signupPolicy.Check(ctx, validEmail)
+			if err != nil {
+				return nil, err
+			}
+			if existingUser.Role == enums.InstitutionAdmin && institutionID != existingUser.InstitutionID {
+				return nil, ErrInstitutionChange
+			}
+			existingUser.InstitutionID = institutionID
+		}
 		existingUser.Email = validEmail
 	}
-	if request.Role.IsValid() {
-		existingUser.Role = request.Role
-	}
-
 	return userService.userRepository.Save(ctx, existingUser)
 }
 
-func (userService *UserServiceImpl) DeleteUser(ctx context.Context, id string) error {
+func (userService *UserServiceImpl) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer tracing.End(span, &err)
+
 	existingUser, err := userService.userRepository.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
 	if existingUser == nil {
-		return errors.New("user not found")
+		return ErrUserNotFound
 	}
 
-	return userService.userRepository.DeleteByID(ctx, id)
+	if err := userService.userRepository.DeleteByID(ctx, id); err != nil {
+		return err
+	}
+
+	userService.logger.InfoContext(ctx, "user deleted", "user_id", id)
+	return nil
+}
+
+func (userService *UserServiceImpl) FindDuplicateEmails(ctx context.Context) (_ []repository.DuplicateEmail, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindDuplicateEmails")
+	defer tracing.End(span, &err)
+
+	return userService.userRepository.FindDuplicateEmails(ctx)
+}
+
+// CheckSignupEmail reports whether email may be used to sign up, so the OTP
+// signup flow can reject it before sending a code.
+func (userService *UserServiceImpl) CheckSignupEmail(ctx context.Context, email string) error {
+	_, err := userService.signupPolicy.Check(ctx, email)
+	return err
+}
+
+// SetPassword replaces the password of the user with the given email. The
+// caller is responsible for having verified the user, e.g. with an OTP.
+func (userService *UserServiceImpl) SetPassword(ctx context.Context, email, password string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetPassword")
+	defer tracing.End(span, &err)
+
+	if password == "" {
+		return errors.New("password is required")
+	}
+
+	user, err := userService.userRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	_, hashSpan := tracing.Start(ctx, "bcrypt.HashPassword")
+	hashedPassword, err := utils.HashPassword(password)
+	hashSpan.End()
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+
+	if _, err := userService.userRepository.Save(ctx, user); err != nil {
+		return err
+	}
+
+	userService.logger.InfoContext(ctx, "password set", "user_id", user.ID.Hex())
+	return nil
+}
+
+// SetUserRole changes a user's role and records the change in the audit
+// log. Only global admins may grant or revoke the global admin role, and
+// nobody may change their own role.
+func (userService *UserServiceImpl) SetUserRole(ctx context.Context, actor Actor, id string, role enums.Role) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetUserRole")
+	defer tracing.End(span, &err)
+
+	if !role.IsValid() {
+		return nil, fmt.Errorf("invalid role %q", role)
+	}
+	if actor.UserID == id {
+		return nil, ErrOwnRoleChange
+	}
+
+	user, err := userService.userRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if (role == enums.Admin || user.Role == enums.Admin) && actor.Role != enums.Admin {
+		return nil, ErrRoleChangeDenied
+	}
+	if role == enums.InstitutionAdmin && user.InstitutionID == "" {
+		return nil, ErrInstitutionAdminScope
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	previousRole := user.Role
+	user.Role = role
+	savedUser, err := userService.userRepository.Save(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	userService.audit(ctx, &model.AuditEvent{
+		Action:        "user.role_changed",
+		ActorID:       actor.UserID,
+		TargetID:      id,
+		InstitutionID: user.InstitutionID,
+		Details:       map[string]string{"from": string(previousRole), "to": string(role)},
+	})
+	return savedUser, nil
+}
+
+// BootstrapAdmin creates the configured global admin while there is none.
+// An existing account with that email is never promoted, since anyone who
+// signed up with it would become an admin; it is reported instead so that
+// startup fails until an operator resolves it.
+func (userService *UserServiceImpl) BootstrapAdmin(ctx context.Context, bootstrapConfig config.BootstrapConfig) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.BootstrapAdmin")
+	defer tracing.End(span, &err)
+
+	if bootstrapConfig.AdminEmail == "" {
+		return nil
+	}
+
+	admins, err := userService.userRepository.FindByFilter(ctx, repository.UserFilter{Role: enums.Admin})
+	if err != nil {
+		return err
+	}
+	if len(admins) > 0 {
+		return nil
+	}
+
+	existing, err := userService.userRepository.FindByEmail(ctx, bootstrapConfig.AdminEmail)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("bootstrap admin %s already has an account; grant it ADMIN with \"user set-role\" or choose another email", bootstrapConfig.AdminEmail)
+	}
+
+	admin, err := userService.createUser(ctx, &request.CreateUserRequest{
+		Name:     bootstrapConfig.AdminName,
+		Email:    bootstrapConfig.AdminEmail,
+		Password: bootstrapConfig.AdminPassword,
+	}, enums.Admin, false)
+	if err != nil {
+		return fmt.Errorf("create bootstrap admin: %w", err)
+	}
+
+	userService.audit(ctx, &model.AuditEvent{
+		Action:   "user.role_changed",
+		TargetID: admin.ID.Hex(),
+		Details:  map[string]string{"from": "", "to": string(enums.Admin), "reason": "bootstrap"},
+	})
+	return nil
+}
+
+func (userService *UserServiceImpl) audit(ctx context.Context, event *model.AuditEvent) {
+	recordAudit(ctx, userService.auditRepository, userService.logger, event)
 }
@@ -0,0 +1,502 @@
+package service
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/data/repository"
+	"Student-Assistant-App/src/dtos/request"
+	"Student-Assistant-App/src/keys"
+	"Student-Assistant-App/src/tenant"
+	"Student-Assistant-App/src/testutil"
+	"Student-Assistant-App/src/utils"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type userServiceFixture struct {
+	userService  UserService
+	userRepo     repository.UserRepository
+	auditRepo    *testutil.AuditRepository
+	institutions *testutil.InstitutionRepository
+}
+
+func newUserServiceFixture(signupConfig config.SignupConfig, institutions ...*model.Institution) *userServiceFixture {
+	userRepo := repository.NewUserRepositoryMemory()
+	auditRepo := &testutil.AuditRepository{}
+	institutionRepo := &testutil.InstitutionRepository{Institutions: institutions}
+	signupPolicy := NewSignupPolicy(institutionRepo, signupConfig)
+	return &userServiceFixture{
+		userService:  NewUserServiceImpl(userRepo, auditRepo, signupPolicy, enums.User, testutil.TokenManager{}, testutil.DiscardLogger),
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		institutions: institutionRepo,
+	}
+}
+
+// addUser stores a user directly, bypassing the signup policy.
+func (f *userServiceFixture) addUser(t *testing.T, email string, role enums.Role, institutionID string) *model.User {
+	t.Helper()
+	hashedPassword, err := utils.HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user, err := f.userRepo.Save(context.Background(), &model.User{
+		Name:          email,
+		Email:         email,
+		Password:      hashedPassword,
+		Role:          role,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return user
+}
+
+func TestCreateUser(t *testing.T) {
+	uniA := &model.Institution{ID: "uni-a", Domains: []string{"uni-a.edu"}}
+
+	tests := []struct {
+		name            string
+		signupConfig    config.SignupConfig
+		institutions    []*model.Institution
+		ctx             context.Context
+		existing        string
+		request         request.CreateUserRequest
+		wantErr         error
+		wantAnyErr      bool
+		wantInstitution string
+	}{
+		{
+			name:    "open signup",
+			request: request.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Password: "secret"},
+		},
+		{
+			name:       "missing name",
+			request:    request.CreateUserRequest{Email: "ada@example.com", Password: "secret"},
+			wantAnyErr: true,
+		},
+		{
+			name:       "missing password",
+			request:    request.CreateUserRequest{Name: "Ada", Email: "ada@example.com"},
+			wantAnyErr: true,
+		},
+		{
+			name:       "invalid email",
+			request:    request.CreateUserRequest{Name: "Ada", Email: "not-an-email", Password: "secret"},
+			wantAnyErr: true,
+		},
+		{
+			name:       "email taken, ignoring case",
+			existing:   "ada@example.com",
+			request:    request.CreateUserRequest{Name: "Ada", Email: "Ada@Example.COM", Password: "secret"},
+			wantAnyErr: true,
+		},
+		{
+			name:            "institution domain",
+			institutions:    []*model.Institution{uniA},
+			request:         request.CreateUserRequest{Name: "Ada", Email: "ada@cs.uni-a.edu", Password: "secret"},
+			wantInstitution: "uni-a",
+		},
+		{
+			name:         "domain outside every institution",
+			institutions: []*model.Institution{uniA},
+			request:      request.CreateUserRequest{Name: "Ada", Email: "ada@example.com", Password: "secret"},
+			wantErr:      ErrEmailDomainNotAllowed,
+		},
+		{
+			name:         "domain of another tenant",
+			institutions: []*model.Institution{uniA, {ID: "uni-b", Domains: []string{"uni-b.edu"}}},
+			ctx:          tenant.WithInstitution(context.Background(), "uni-b"),
+			request:      request.CreateUserRequest{Name: "Ada", Email: "ada@uni-a.edu", Password: "secret"},
+			wantErr:      ErrEmailDomainNotAllowed,
+		},
+		{
+			name:         "disposable address",
+			signupConfig: config.SignupConfig{BlockDisposable: true},
+			request:      request.CreateUserRequest{Name: "Ada", Email: "ada@10minutemail.com", Password: "secret"},
+			wantErr:      ErrDisposableEmail,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserServiceFixture(test.signupConfig, test.institutions...)
+			if test.existing != "" {
+				fixture.addUser(t, test.existing, enums.User, "")
+			}
+			ctx := test.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			createUserResponse, err := fixture.userService.CreateUser(ctx, &test.request)
+			switch {
+			case test.wantErr != nil:
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("CreateUser error = %v, want %v", err, test.wantErr)
+				}
+				return
+			case test.wantAnyErr:
+				if err == nil {
+					t.Fatal("CreateUser succeeded, want an error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("CreateUser: %v", err)
+			}
+
+			user := createUserResponse.User
+			if user.Role != enums.User || user.InstitutionID != test.wantInstitution {
+				t.Errorf("user role %s institution %q, want USER %q", user.Role, user.InstitutionID, test.wantInstitution)
+			}
+			if user.Password == test.request.Password || !utils.CheckPassword(test.request.Password, user.Password) {
+				t.Error("password was not stored as a bcrypt hash")
+			}
+			if createUserResponse.Token != "token-"+user.ID.Hex() {
+				t.Errorf("token = %q", createUserResponse.Token)
+			}
+		})
+	}
+}
+
+func TestCreateUserWithRole(t *testing.T) {
+	uniA := &model.Institution{ID: "uni-a", Domains: []string{"uni-a.edu"}}
+
+	tests := []struct {
+		name            string
+		role            enums.Role
+		email           string
+		wantErr         error
+		wantInstitution string
+	}{
+		{name: "user in an institution", role: enums.User, email: "ada@uni-a.edu", wantInstitution: "uni-a"},
+		{name: "user outside every institution", role: enums.User, email: "ada@example.com", wantErr: ErrEmailDomainNotAllowed},
+		// Admins are global, whatever the signup policy says of their email.
+		{name: "admin with an institution domain", role: enums.Admin, email: "ada@uni-a.edu"},
+		{name: "admin outside every institution", role: enums.Admin, email: "ada@example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserServiceFixture(config.SignupConfig{}, uniA)
+
+			createUserResponse, err := fixture.userService.CreateUserWithRole(context.Background(), &request.CreateUserRequest{
+				Name: "Ada", Email: test.email, Password: "secret",
+			}, test.role)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("CreateUserWithRole error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateUserWithRole: %v", err)
+			}
+
+			user := createUserResponse.User
+			if user.Role != test.role || user.InstitutionID != test.wantInstitution {
+				t.Errorf("user role %s institution %q, want %s %q", user.Role, user.InstitutionID, test.role, test.wantInstitution)
+			}
+			if createUserResponse.Token != "token-"+user.ID.Hex() {
+				t.Errorf("token = %q", createUserResponse.Token)
+			}
+		})
+	}
+}
+
+// noSigningKey is a token manager on a fresh database, before any key exists.
+type noSigningKey struct{ testutil.TokenManager }
+
+func (noSigningKey) Issue(userID, email string, role enums.Role, institutionID string) (string, error) {
+	return "", keys.ErrNoSigningKey
+}
+
+func TestCreateAccountWithoutSigningKey(t *testing.T) {
+	userRepo := repository.NewUserRepositoryMemory()
+	signupPolicy := NewSignupPolicy(&testutil.InstitutionRepository{}, config.SignupConfig{})
+	userService := NewUserServiceImpl(userRepo, &testutil.AuditRepository{}, signupPolicy, enums.User, noSigningKey{}, testutil.DiscardLogger)
+
+	for _, role := range []enums.Role{enums.Admin, enums.User, enums.Guest} {
+		email := strings.ToLower(string(role)) + "@example.com"
+		user, err := userService.CreateAccount(context.Background(), &request.CreateUserRequest{
+			Name: "Ada", Email: email, Password: "secret",
+		}, role)
+		if err != nil {
+			t.Fatalf("CreateAccount(%s): %v", role, err)
+		}
+		if user.Role != role {
+			t.Errorf("role = %s, want %s", user.Role, role)
+		}
+	}
+
+	if _, err := userService.CreateAccount(context.Background(), &request.CreateUserRequest{
+		Name: "Ada", Email: "owner@example.com", Password: "secret",
+	}, "OWNER"); err == nil {
+		t.Error("CreateAccount accepted an invalid role")
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		actorRole  enums.Role
+		targetRole enums.Role
+		targetInst string
+		self       bool
+		missing    bool
+		role       enums.Role
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{name: "admin promotes to admin", actorRole: enums.Admin, targetRole: enums.User, role: enums.Admin},
+		{name: "institution admin demotes to guest", actorRole: enums.InstitutionAdmin, targetRole: enums.User, role: enums.Guest},
+		{name: "institution admin cannot grant admin", actorRole: enums.InstitutionAdmin, targetRole: enums.User, role: enums.Admin, wantErr: ErrRoleChangeDenied},
+		{name: "institution admin cannot demote an admin", actorRole: enums.InstitutionAdmin, targetRole: enums.Admin, role: enums.User, wantErr: ErrRoleChangeDenied},
+		{name: "own role", actorRole: enums.Admin, targetRole: enums.Admin, self: true, role: enums.User, wantErr: ErrOwnRoleChange},
+		{name: "institution admin needs an institution", actorRole: enums.Admin, targetRole: enums.User, role: enums.InstitutionAdmin, wantErr: ErrInstitutionAdminScope},
+		{name: "institution admin within an institution", actorRole: enums.Admin, targetRole: enums.User, targetInst: "uni-a", role: enums.InstitutionAdmin},
+		{name: "invalid role", actorRole: enums.Admin, targetRole: enums.User, role: "OWNER", wantAnyErr: true},
+		{name: "unknown user", actorRole: enums.Admin, missing: true, role: enums.Guest, wantErr: ErrUserNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserServiceFixture(config.SignupConfig{})
+			target := fixture.addUser(t, "target@example.com", test.targetRole, test.targetInst)
+			targetID := target.ID.Hex()
+			if test.missing {
+				targetID = "5f0000000000000000000000"
+			}
+			actor := Actor{UserID: "someone-else", Role: test.actorRole}
+			if test.self {
+				actor.UserID = targetID
+			}
+
+			user, err := fixture.userService.SetUserRole(context.Background(), actor, targetID, test.role)
+			switch {
+			case test.wantErr != nil:
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("SetUserRole error = %v, want %v", err, test.wantErr)
+				}
+				return
+			case test.wantAnyErr:
+				if err == nil {
+					t.Fatal("SetUserRole succeeded, want an error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("SetUserRole: %v", err)
+			}
+
+			if user.Role != test.role {
+				t.Errorf("role = %s, want %s", user.Role, test.role)
+			}
+			if len(fixture.auditRepo.Events()) != 1 || fixture.auditRepo.Events()[0].Action != "user.role_changed" {
+				t.Errorf("audit events = %+v, want one user.role_changed", fixture.auditRepo.Events())
+			}
+		})
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	uniA := &model.Institution{ID: "uni-a", Domains: []string{"uni-a.edu"}}
+	bootstrapConfig := config.BootstrapConfig{AdminEmail: "root@uni-a.edu", AdminPassword: "secret", AdminName: "Root"}
+
+	tests := []struct {
+		name        string
+		existing    enums.Role
+		otherAdmin  bool
+		wantCreated bool
+		wantAnyErr  bool
+	}{
+		{name: "creates the admin", wantCreated: true},
+		{name: "an admin already exists", otherAdmin: true},
+		{name: "email taken by a user", existing: enums.User, wantAnyErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserServiceFixture(config.SignupConfig{}, uniA)
+			if test.existing != "" {
+				fixture.addUser(t, bootstrapConfig.AdminEmail, test.existing, "uni-a")
+			}
+			if test.otherAdmin {
+				fixture.addUser(t, "admin@example.com", enums.Admin, "")
+			}
+
+			err := fixture.userService.BootstrapAdmin(context.Background(), bootstrapConfig)
+			if test.wantAnyErr {
+				if err == nil {
+					t.Fatal("BootstrapAdmin succeeded, want an error")
+				}
+			} else if err != nil {
+				t.Fatalf("BootstrapAdmin: %v", err)
+			}
+
+			user, err := fixture.userRepo.FindByEmail(context.Background(), bootstrapConfig.AdminEmail)
+			if err != nil {
+				t.Fatalf("FindByEmail: %v", err)
+			}
+			switch {
+			case test.wantCreated:
+				// The signup policy would have put this address in uni-a.
+				if user == nil || user.Role != enums.Admin || user.InstitutionID != "" {
+					t.Fatalf("bootstrap admin = %+v, want a global admin", user)
+				}
+			case test.existing != "":
+				if user.Role != test.existing {
+					t.Errorf("existing account was changed to %s", user.Role)
+				}
+			case user != nil:
+				t.Errorf("created %+v although an admin exists", user)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	institutions := []*model.Institution{
+		{ID: "uni-a", Domains: []string{"uni-a.edu"}},
+		{ID: "uni-b", Domains: []string{"uni-b.edu"}},
+	}
+
+	tests := []struct {
+		name            string
+		institutions    []*model.Institution
+		role            enums.Role
+		request         request.UpdateUserRequest
+		wantEmail       string
+		wantName        string
+		wantInstitution string
+		wantErr         error
+		wantAnyErr      bool
+	}{
+		{name: "rename", request: request.UpdateUserRequest{Name: "Ada L."}, wantEmail: "ada@uni-a.edu", wantName: "Ada L.", wantInstitution: "uni-a"},
+		{name: "new email is normalised", request: request.UpdateUserRequest{Email: "Ada.L@Example.com"}, wantEmail: "ada.l@example.com", wantName: "ada@uni-a.edu"},
+		{name: "email of another user", request: request.UpdateUserRequest{Email: "grace@example.com"}, wantAnyErr: true},
+		{name: "invalid email", request: request.UpdateUserRequest{Email: "nope"}, wantAnyErr: true},
+		{
+			name:            "email moves the user to its institution",
+			institutions:    institutions,
+			request:         request.UpdateUserRequest{Email: "ada@uni-b.edu"},
+			wantEmail:       "ada@uni-b.edu",
+			wantName:        "ada@uni-a.edu",
+			wantInstitution: "uni-b",
+		},
+		{
+			name:         "email outside every institution",
+			institutions: institutions,
+			request:      request.UpdateUserRequest{Email: "ada@example.com"},
+			wantErr:      ErrEmailDomainNotAllowed,
+		},
+		{
+			name:         "institution admin cannot change institution",
+			institutions: institutions,
+			role:         enums.InstitutionAdmin,
+			request:      request.UpdateUserRequest{Email: "ada@uni-b.edu"},
+			wantErr:      ErrInstitutionChange,
+		},
+		{
+			name:         "global admin stays global",
+			institutions: institutions,
+			role:         enums.Admin,
+			request:      request.UpdateUserRequest{Email: "ada@example.com"},
+			wantEmail:    "ada@example.com",
+			wantName:     "ada@uni-a.edu",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newUserServiceFixture(config.SignupConfig{}, test.institutions...)
+			role := test.role
+			if role == "" {
+				role = enums.User
+			}
+			institutionID := "uni-a"
+			if role == enums.Admin {
+				institutionID = ""
+			}
+			user := fixture.addUser(t, "ada@uni-a.edu", role, institutionID)
+			fixture.addUser(t, "grace@example.com", enums.User, "")
+
+			updated, err := fixture.userService.UpdateUser(context.Background(), user.ID.Hex(), &test.request)
+			switch {
+			case test.wantErr != nil:
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("UpdateUser error = %v, want %v", err, test.wantErr)
+				}
+				return
+			case test.wantAnyErr:
+				if err == nil {
+					t.Fatal("UpdateUser succeeded, want an error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("UpdateUser: %v", err)
+			}
+			if updated.Email != test.wantEmail || updated.Name != test.wantName || updated.InstitutionID != test.wantInstitution {
+				t.Errorf("user = %s <%s> in %q, want %s <%s> in %q",
+					updated.Name, updated.Email, updated.InstitutionID, test.wantName, test.wantEmail, test.wantInstitution)
+			}
+		})
+	}
+}
+
+func TestUserServiceTenantScope(t *testing.T) {
+	fixture := newUserServiceFixture(config.SignupConfig{})
+	other := fixture.addUser(t, "b@uni-b.edu", enums.User, "uni-b")
+	ctx := tenant.WithInstitution(context.Background(), "uni-a")
+
+	if user, err := fixture.userService.GetUserByID(ctx, other.ID.Hex()); err != nil || user != nil {
+		t.Errorf("GetUserByID across tenants = %v, %v; want nil", user, err)
+	}
+	if err := fixture.userService.DeleteUser(ctx, other.ID.Hex()); err == nil {
+		t.Error("DeleteUser across tenants succeeded")
+	}
+	if user, _ := fixture.userService.GetUserByID(context.Background(), other.ID.Hex()); user == nil {
+		t.Error("the user was deleted from another tenant")
+	}
+}
+
+func TestSetPasswordAndLogin(t *testing.T) {
+	fixture := newUserServiceFixture(config.SignupConfig{})
+	fixture.addUser(t, "ada@example.com", enums.User, "")
+	authService := NewAuthService(fixture.userService, testutil.TokenManager{}, testutil.DiscardLogger)
+	ctx := context.Background()
+
+	if err := fixture.userService.SetPassword(ctx, "ada@example.com", "new-password"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if err := fixture.userService.SetPassword(ctx, "nobody@example.com", "new-password"); err == nil {
+		t.Error("SetPassword for an unknown user succeeded")
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  bool
+	}{
+		{"new password", "ada@example.com", "new-password", false},
+		{"email in another case", "ADA@example.com", "new-password", false},
+		{"old password", "ada@example.com", "password", true},
+		{"unknown user", "nobody@example.com", "new-password", true},
+		{"missing password", "ada@example.com", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loginResponse, err := authService.Login(ctx, &request.LoginRequest{Email: test.email, Password: test.password})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Login error = %v, want error %v", err, test.wantErr)
+			}
+			if err == nil && loginResponse.Token == "" {
+				t.Error("Login returned no token")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package session
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/utils"
+	"crypto/hmac"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cookies issues and reads cookie sessions for the web client. The session
+// cookie holds the access token and is HttpOnly. The CSRF cookie is readable
+// by the frontend, which must echo it in a header on state-changing
+// requests. CSRF tokens are bound to the session token with an HMAC, so a
+// cookie planted from a sibling subdomain does not pass.
+type Cookies struct {
+	config config.SessionConfig
+	secret string
+	ttl    time.Duration
+}
+
+func New(sessionConfig config.SessionConfig, jwtConfig config.JWTConfig) *Cookies {
+	return &Cookies{
+		config: sessionConfig,
+		secret: jwtConfig.Secret,
+		ttl:    jwtConfig.TTL,
+	}
+}
+
+// Enabled reports whether the deployment uses cookie sessions.
+func (c *Cookies) Enabled() bool {
+	return c.config.Mode == "cookie"
+}
+
+// Start sets the session and CSRF cookies for token and returns the CSRF
+// token, which frontends on another origin cannot read from the cookie.
+func (c *Cookies) Start(ctx *gin.Context, token string) (string, error) {
+	nonce, err := utils.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	csrfToken := nonce + "." + c.csrfMAC(nonce, token)
+
+	maxAge := int(c.ttl.Seconds())
+	c.setCookie(ctx, c.config.CookieName, token, maxAge, true)
+	c.setCookie(ctx, c.config.CSRFCookieName, csrfToken, maxAge, false)
+	return csrfToken, nil
+}
+
+// End clears the session and CSRF cookies.
+func (c *Cookies) End(ctx *gin.Context) {
+	c.setCookie(ctx, c.config.CookieName, "", -1, true)
+	c.setCookie(ctx, c.config.CSRFCookieName, "", -1, false)
+}
+
+// Token returns the access token from the session cookie, if any.
+func (c *Cookies) Token(ctx *gin.Context) (string, bool) {
+	if !c.Enabled() {
+		return "", false
+	}
+	token, err := ctx.Cookie(c.config.CookieName)
+	if err != nil || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// ValidCSRF reports whether the CSRF header matches the CSRF cookie and
+// both belong to the session token.
+func (c *Cookies) ValidCSRF(ctx *gin.Context, token string) bool {
+	header := ctx.GetHeader(c.config.CSRFHeader)
+	cookie, err := ctx.Cookie(c.config.CSRFCookieName)
+	if err != nil || header == "" || header != cookie {
+		return false
+	}
+	nonce, mac, ok := strings.Cut(header, ".")
+	return ok && hmac.Equal([]byte(c.csrfMAC(nonce, token)), []byte(mac))
+}
+
+// CSRFHeader names the header that must carry the CSRF token.
+func (c *Cookies) CSRFHeader() string {
+	return c.config.CSRFHeader
+}
+
+func (c *Cookies) csrfMAC(nonce, token string) string {
+	return utils.HMAC(nonce+"."+utils.HashToken(token), c.secret)
+}
+
+func (c *Cookies) setCookie(ctx *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	switch c.config.SameSite {
+	case "strict":
+		ctx.SetSameSite(http.SameSiteStrictMode)
+	case "none":
+		ctx.SetSameSite(http.SameSiteNoneMode)
+	default:
+		ctx.SetSameSite(http.SameSiteLaxMode)
+	}
+	ctx.SetCookie(name, value, maxAge, "/", c.config.CookieDomain, c.config.CookieSecure, httpOnly)
+}
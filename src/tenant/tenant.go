@@ -0,0 +1,19 @@
+package tenant
+
+import "context"
+
+type contextKey struct{}
+
+// WithInstitution scopes ctx to one institution. Repositories that hold
+// tenant data restrict their queries to the institution in the context.
+func WithInstitution(ctx context.Context, institutionID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, institutionID)
+}
+
+// InstitutionFromContext returns the institution ctx is scoped to. ok is
+// false for unscoped contexts, such as global administrators and background
+// jobs.
+func InstitutionFromContext(ctx context.Context) (institutionID string, ok bool) {
+	institutionID, ok = ctx.Value(contextKey{}).(string)
+	return institutionID, ok && institutionID != ""
+}
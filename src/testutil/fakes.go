@@ -0,0 +1,213 @@
+// Package testutil holds the fakes shared by the service and controller
+// tests. Nothing outside tests should import it.
+package testutil
+
+import (
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/tokens"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var DiscardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// EmailService records the codes and welcome emails it is asked to send.
+type EmailService struct {
+	mu       sync.Mutex
+	codes    map[string]string
+	welcomed []string
+	fail     error
+}
+
+func NewEmailService() *EmailService {
+	return &EmailService{codes: make(map[string]string)}
+}
+
+// Fail makes every later SendOTP return err; nil restores delivery.
+func (e *EmailService) Fail(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fail = err
+}
+
+func (e *EmailService) SendOTP(ctx context.Context, email, otp, purpose string, validFor time.Duration) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.fail != nil {
+		return e.fail
+	}
+	e.codes[email+"/"+purpose] = otp
+	return nil
+}
+
+// LastCode returns the last code sent to email for purpose.
+func (e *EmailService) LastCode(email, purpose string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.codes[email+"/"+purpose]
+}
+
+// Sent returns how many addresses were sent a code for some purpose.
+func (e *EmailService) Sent() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.codes)
+}
+
+func (e *EmailService) SendWelcomeEmail(ctx context.Context, email, name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.welcomed = append(e.welcomed, email)
+	return nil
+}
+
+// Welcomed returns the addresses sent a welcome email, in order.
+func (e *EmailService) Welcomed() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.welcomed)
+}
+
+func (e *EmailService) SendInvitation(ctx context.Context, email, inviterName, acceptLink string) error {
+	return nil
+}
+
+func (e *EmailService) CheckConnection(ctx context.Context) error {
+	return nil
+}
+
+// AuditRepository records audit events in memory.
+type AuditRepository struct {
+	mu     sync.Mutex
+	events []model.AuditEvent
+}
+
+func (r *AuditRepository) Record(ctx context.Context, event *model.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// Events returns the recorded events, in order.
+func (r *AuditRepository) Events() []model.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// InstitutionRepository serves the given institutions. With none, signup
+// is open.
+type InstitutionRepository struct {
+	Institutions []*model.Institution
+}
+
+func (r *InstitutionRepository) Create(ctx context.Context, institution *model.Institution) (*model.Institution, error) {
+	r.Institutions = append(r.Institutions, institution)
+	return institution, nil
+}
+
+func (r *InstitutionRepository) Save(ctx context.Context, institution *model.Institution) (*model.Institution, error) {
+	return institution, nil
+}
+
+func (r *InstitutionRepository) FindByID(ctx context.Context, id string) (*model.Institution, error) {
+	for _, institution := range r.Institutions {
+		if institution.ID == id {
+			return institution, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InstitutionRepository) FindByDomains(ctx context.Context, domains []string) ([]*model.Institution, error) {
+	var found []*model.Institution
+	for _, institution := range r.Institutions {
+		for _, domain := range institution.Domains {
+			if slices.Contains(domains, domain) {
+				found = append(found, institution)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *InstitutionRepository) FindAll(ctx context.Context) ([]*model.Institution, error) {
+	return r.Institutions, nil
+}
+
+func (r *InstitutionRepository) Count(ctx context.Context) (int64, error) {
+	return int64(len(r.Institutions)), nil
+}
+
+func (r *InstitutionRepository) DeleteByID(ctx context.Context, id string) error {
+	r.Institutions = slices.DeleteFunc(r.Institutions, func(institution *model.Institution) bool {
+		return institution.ID == id
+	})
+	return nil
+}
+
+// IdentityRepository keeps SSO identities in memory.
+type IdentityRepository struct {
+	mu         sync.Mutex
+	identities []model.Identity
+}
+
+func (r *IdentityRepository) Save(ctx context.Context, identity *model.Identity) (*model.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity.ID = primitive.NewObjectID()
+	r.identities = append(r.identities, *identity)
+	return identity, nil
+}
+
+func (r *IdentityRepository) FindByProviderAndSubject(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *IdentityRepository) TouchLastLogin(ctx context.Context, id primitive.ObjectID) error {
+	return nil
+}
+
+func (r *IdentityRepository) DeleteByID(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.identities = slices.DeleteFunc(r.identities, func(identity model.Identity) bool {
+		return identity.ID == id
+	})
+	return nil
+}
+
+// Len returns the number of linked identities.
+func (r *IdentityRepository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.identities)
+}
+
+// TokenManager issues opaque tokens naming the user.
+type TokenManager struct{}
+
+func (TokenManager) Issue(userID, email string, role enums.Role, institutionID string) (string, error) {
+	return "token-" + userID, nil
+}
+
+func (TokenManager) Validate(ctx context.Context, token string) (*tokens.Claims, error) {
+	return nil, errors.New("not supported")
+}
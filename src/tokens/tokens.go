@@ -0,0 +1,145 @@
+package tokens
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/enums"
+	"Student-Assistant-App/src/keys"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms signed with the key store. HS256 is accepted in addition only
+// while legacy tokens drain, and always verifies against the shared secret.
+var asymmetricAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+var (
+	ErrInvalidToken      = errors.New("invalid token")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match its key")
+	ErrMissingClaim      = errors.New("token is missing a required claim")
+)
+
+type Claims struct {
+	UserID string     `json:"user_id"`
+	Email  string     `json:"email"`
+	Role   enums.Role `json:"role"`
+	// InstitutionID is the tenant the user belongs to; empty for users outside
+	// any institution, including global admins.
+	InstitutionID string `json:"institution_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Manager issues and validates access tokens.
+type Manager interface {
+	Issue(userID, email string, role enums.Role, institutionID string) (string, error)
+	Validate(ctx context.Context, token string) (*Claims, error)
+}
+
+type ManagerImpl struct {
+	config   config.JWTConfig
+	keyStore *keys.Store
+	now      func() time.Time
+}
+
+func NewManager(jwtConfig config.JWTConfig, keyStore *keys.Store) Manager {
+	return &ManagerImpl{
+		config:   jwtConfig,
+		keyStore: keyStore,
+		now:      time.Now,
+	}
+}
+
+// Issue signs a token with the store's active key and names the key in the
+// kid header so verifiers can pick it from the JWKS.
+func (m *ManagerImpl) Issue(userID, email string, role enums.Role, institutionID string) (string, error) {
+	key, err := m.keyStore.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	now := m.now()
+	claims := &Claims{
+		UserID:        userID,
+		Email:         email,
+		Role:          role,
+		InstitutionID: institutionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.config.Issuer,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{m.config.Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.config.TTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Validate accepts only allowlisted algorithms and verifies each token with
+// the key its kid header names, refusing a token whose alg differs from the
+// key's. Expiry and issued-at are required on every token and checked with
+// the configured leeway; not-before is required on every token this manager
+// issues. Legacy HS256 tokens were issued without nbf, iss or aud and are
+// accepted without them only while AcceptHS256 is set.
+func (m *ManagerImpl) Validate(ctx context.Context, tokenStr string) (*Claims, error) {
+	validMethods := asymmetricAlgorithms
+	if m.config.AcceptHS256 {
+		validMethods = append(validMethods[:len(validMethods):len(validMethods)], "HS256")
+	}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(validMethods),
+		jwt.WithLeeway(m.config.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(m.now),
+	)
+
+	claims := &Claims{}
+	token, err := parser.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() == "HS256" {
+			if _, hasKID := token.Header["kid"]; hasKID {
+				return nil, ErrAlgorithmMismatch
+			}
+			return []byte(m.config.Secret), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, err := m.keyStore.VerificationKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.Algorithm != token.Method.Alg() {
+			return nil, ErrAlgorithmMismatch
+		}
+		return key.Public, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	if claims.IssuedAt == nil || claims.UserID == "" {
+		return nil, ErrMissingClaim
+	}
+
+	if token.Method.Alg() != "HS256" {
+		if claims.NotBefore == nil {
+			return nil, ErrMissingClaim
+		}
+		if claims.Issuer != m.config.Issuer {
+			return nil, jwt.ErrTokenInvalidIssuer
+		}
+		if !slices.Contains(claims.Audience, m.config.Audience) {
+			return nil, jwt.ErrTokenInvalidAudience
+		}
+	}
+
+	return claims, nil
+}
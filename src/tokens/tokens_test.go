@@ -0,0 +1,310 @@
+package tokens
+
+import (
+	"Student-Assistant-App/src/config"
+	"Student-Assistant-App/src/data/model"
+	"Student-Assistant-App/src/keys"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyRepository keeps signing keys in memory.
+type keyRepository struct {
+	mu   sync.Mutex
+	keys []*model.SigningKey
+}
+
+func (r *keyRepository) Save(ctx context.Context, key *model.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *keyRepository) FindUnexpired(ctx context.Context) ([]*model.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.SigningKey(nil), r.keys...), nil
+}
+
+func testConfig(algorithm string) config.JWTConfig {
+	jwtConfig := config.Default().JWT
+	jwtConfig.Secret = "test-secret"
+	jwtConfig.KeyEncryptionKey = "test-key-encryption-key"
+	jwtConfig.Algorithm = algorithm
+	return jwtConfig
+}
+
+func newTestManager(t *testing.T, jwtConfig config.JWTConfig) (*ManagerImpl, *keys.Store) {
+	t.Helper()
+	keyStore, err := keys.NewStore(&keyRepository{}, jwtConfig, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := keyStore.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	return NewManager(jwtConfig, keyStore).(*ManagerImpl), keyStore
+}
+
+func TestIssueAndValidate(t *testing.T) {
+	for _, algorithm := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(algorithm, func(t *testing.T) {
+			manager, _ := newTestManager(t, testConfig(algorithm))
+			token, err := manager.Issue("user-1", "a@uni.edu", "USER", "uni-a")
+			if err != nil {
+				t.Fatalf("Issue: %v", err)
+			}
+
+			claims, err := manager.Validate(context.Background(), token)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if claims.UserID != "user-1" || claims.Email != "a@uni.edu" || claims.Role != "USER" || claims.InstitutionID != "uni-a" {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestSigningKeysEncryptedAtRest(t *testing.T) {
+	jwtConfig := testConfig("RS256")
+	repository := &keyRepository{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	keyStore, err := keys.NewStore(repository, jwtConfig, logger)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := keyStore.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	stored, _ := repository.FindUnexpired(context.Background())
+	if len(stored) != 1 || strings.Contains(stored[0].PrivateKey, "PRIVATE KEY") {
+		t.Fatalf("stored keys = %+v, want one encrypted key", stored)
+	}
+	token, err := NewManager(jwtConfig, keyStore).Issue("user-1", "a@uni.edu", "USER", "")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	// Another key encryption key cannot read the stored key, so the store
+	// creates its own and earlier tokens no longer validate.
+	jwtConfig.KeyEncryptionKey = "another-key-encryption-key"
+	otherStore, err := keys.NewStore(repository, jwtConfig, logger)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := otherStore.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, err := NewManager(jwtConfig, otherStore).Validate(context.Background(), token); err == nil {
+		t.Error("a key sealed with another key encryption key was used")
+	}
+}
+
+func TestValidateRejectsTamperedTokens(t *testing.T) {
+	manager, _ := newTestManager(t, testConfig("RS256"))
+	token, err := manager.Issue("user-1", "a@uni.edu", "USER", "")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	parts := strings.Split(token, ".")
+
+	forgedPayload := base64.RawURLEncoding.EncodeToString([]byte(`{"user_id":"user-1","email":"a@uni.edu","role":"ADMIN"}`))
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	signature[0] ^= 0xff
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"payload replaced", parts[0] + "." + forgedPayload + "." + parts[2]},
+		{"signature altered", parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(signature)},
+		{"signature removed", parts[0] + "." + parts[1] + "."},
+		{"not a token", "not-a-token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := manager.Validate(context.Background(), test.token); err == nil {
+				t.Error("Validate accepted a tampered token")
+			}
+		})
+	}
+}
+
+func TestValidateRejectsAlgorithmSwaps(t *testing.T) {
+	// Accepting HS256 is the riskiest setting for key confusion.
+	jwtConfig := testConfig("RS256")
+	jwtConfig.AcceptHS256 = true
+	manager, keyStore := newTestManager(t, jwtConfig)
+	key, err := keyStore.SigningKey()
+	if err != nil {
+		t.Fatalf("SigningKey: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(key.Public)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	now := time.Now()
+	claims := &Claims{
+		UserID: "user-1",
+		Role:   "ADMIN",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    manager.config.Issuer,
+			Audience:  jwt.ClaimStrings{manager.config.Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	sign := func(method jwt.SigningMethod, kid string, secret any) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(secret)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"HS256 keyed with the RSA public key", sign(jwt.SigningMethodHS256, key.ID, publicPEM), ErrAlgorithmMismatch},
+		{"HS256 keyed with the RSA public key, no kid", sign(jwt.SigningMethodHS256, "", publicPEM), jwt.ErrTokenSignatureInvalid},
+		{"none", sign(jwt.SigningMethodNone, key.ID, jwt.UnsafeAllowNoneSignatureType), jwt.ErrTokenSignatureInvalid},
+		{"RS384 is not allowlisted", sign(jwt.SigningMethodRS384, key.ID, key.Private), jwt.ErrTokenSignatureInvalid},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "unknown", key.Private), keys.ErrUnknownKey},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := manager.Validate(context.Background(), test.token)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("Validate error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+
+	// The header claims ES256 while the kid names an RSA key.
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"` + key.ID + `","typ":"JWT"}`))
+	parts := strings.Split(sign(jwt.SigningMethodRS256, key.ID, key.Private), ".")
+	if _, err := manager.Validate(context.Background(), header+"."+parts[1]+"."+parts[2]); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("ES256 header on an RSA key: error = %v, want %v", err, ErrAlgorithmMismatch)
+	}
+}
+
+func TestValidateClaims(t *testing.T) {
+	jwtConfig := testConfig("ES256")
+	manager, keyStore := newTestManager(t, jwtConfig)
+	key, err := keyStore.SigningKey()
+	if err != nil {
+		t.Fatalf("SigningKey: %v", err)
+	}
+
+	issued := time.Now()
+	valid := func() *Claims {
+		return &Claims{
+			UserID: "user-1",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    jwtConfig.Issuer,
+				Audience:  jwt.ClaimStrings{jwtConfig.Audience},
+				ExpiresAt: jwt.NewNumericDate(issued.Add(time.Hour)),
+				NotBefore: jwt.NewNumericDate(issued),
+				IssuedAt:  jwt.NewNumericDate(issued),
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(*Claims)
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", func(*Claims) {}, issued, nil},
+		{"expired within leeway", func(*Claims) {}, issued.Add(time.Hour + jwtConfig.Leeway/2), nil},
+		{"expired beyond leeway", func(*Claims) {}, issued.Add(time.Hour + 2*jwtConfig.Leeway), jwt.ErrTokenExpired},
+		{"not yet valid", func(*Claims) {}, issued.Add(-2 * jwtConfig.Leeway), jwt.ErrTokenNotValidYet},
+		{"missing exp", func(c *Claims) { c.ExpiresAt = nil }, issued, jwt.ErrTokenRequiredClaimMissing},
+		{"missing iat", func(c *Claims) { c.IssuedAt = nil }, issued, ErrMissingClaim},
+		{"missing nbf", func(c *Claims) { c.NotBefore = nil }, issued, ErrMissingClaim},
+		{"missing user", func(c *Claims) { c.UserID = "" }, issued, ErrMissingClaim},
+		{"wrong issuer", func(c *Claims) { c.Issuer = "someone-else" }, issued, jwt.ErrTokenInvalidIssuer},
+		{"wrong audience", func(c *Claims) { c.Audience = jwt.ClaimStrings{"someone-else"} }, issued, jwt.ErrTokenInvalidAudience},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := valid()
+			test.edit(claims)
+			token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+			token.Header["kid"] = key.ID
+			signed, err := token.SignedString(key.Private)
+			if err != nil {
+				t.Fatalf("SignedString: %v", err)
+			}
+
+			manager.now = func() time.Time { return test.now }
+			_, err = manager.Validate(context.Background(), signed)
+			if test.wantErr == nil && err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("Validate error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateLegacyHS256(t *testing.T) {
+	// The claims the pre-tokens utils.GenerateJWT issued: no nbf, iss or aud.
+	now := time.Now()
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "user-1",
+		"email":   "user@example.com",
+		"role":    "USER",
+		"exp":     now.Add(24 * time.Hour).Unix(),
+		"iat":     now.Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		acceptHS256 bool
+		wantErr     error
+	}{
+		{"accepted while draining", legacy, true, nil},
+		{"rejected once disabled", legacy, false, jwt.ErrTokenSignatureInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jwtConfig := testConfig("RS256")
+			jwtConfig.AcceptHS256 = test.acceptHS256
+			manager, _ := newTestManager(t, jwtConfig)
+
+			_, err := manager.Validate(context.Background(), test.token)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("Validate error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
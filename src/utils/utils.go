@@ -1,27 +1,19 @@
 package utils
 
 import (
-	"Student-Assistant-App/src/data/enums"
-	"errors"
-	"os"
-	"time"
-
-	"github.com/dgrijalva/jwt-go"
-	"golang.org/x/crypto/bcrypt"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"regexp"
+	"strings"
 
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/idna"
 )
 
-
-type Claims struct {
-	UserID string     `json:"user_id"`
-	Email  string     `json:"email"`
-	Role   enums.Role `json:"role"`
-	jwt.StandardClaims
-}
-
-
 type InvalidEmailRegexError struct {
 	Email string
 }
@@ -30,17 +22,55 @@ func (e *InvalidEmailRegexError) Error() string {
 	return fmt.Sprintf("Invalid email format: %s", e.Email)
 }
 
+// emailRegex accepts normalised addresses, whose internationalised top-level
+// domains are in punycode form, e.g. "xn--p1ai".
+var emailRegex = regexp.MustCompile("^[a-z0-9._%+-]+@[a-z0-9.-]+\\.([a-z]{2,}|xn--[a-z0-9-]+)$")
+
+// NormalizeEmail returns the canonical form of an address: surrounding space
+// trimmed, local part lower-cased and the domain converted to its lower-case
+// ASCII (punycode) form, so "Alice@Uni.EDU" and "alice@uni.edu" are the same
+// identity. Addresses that cannot be normalised are returned trimmed and
+// lower-cased along with an error.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return strings.ToLower(email), &InvalidEmailRegexError{Email: email}
+	}
+
+	local := strings.ToLower(email[:at])
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(email[at+1:], "."))
+	if err != nil {
+		return strings.ToLower(email), &InvalidEmailRegexError{Email: email}
+	}
+	return local + "@" + strings.ToLower(domain), nil
+}
+
+// EmailVerification normalises email and checks that the result is a valid
+// address, returning the canonical form.
 func EmailVerification(email string) (string, error) {
-	regex := "^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\\.[a-zA-Z]{2,}$"
-	re := regexp.MustCompile(regex)
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		return "", err
+	}
 
-	if re.MatchString(email) {
-		return email, nil
+	if emailRegex.MatchString(normalized) {
+		return normalized, nil
 	}
 
 	return "", &InvalidEmailRegexError{Email: email}
 }
 
+// NormalizeDomain returns the lower-case ASCII (punycode) form of a domain
+// without surrounding space or a trailing dot.
+func NormalizeDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+		domain = ascii
+	}
+	return strings.ToLower(domain)
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
@@ -51,45 +81,56 @@ func CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
-func GenerateJWT(userID, email string, role enums.Role) (string, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", errors.New("JWT_SECRET not set")
-	}
-
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-	}
+// HashOTP returns the keyed hash under which an OTP code is stored, so that
+// codes never sit in the database in clear text.
+func HashOTP(code, secret string) string {
+	return HMAC(code, secret)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtSecret))
+// CheckOTP compares a submitted code against a stored hash in constant time.
+func CheckOTP(code, hash, secret string) bool {
+	return hmac.Equal([]byte(HashOTP(code, secret)), []byte(hash))
 }
 
-func ValidateJWT(tokenStr string) (*Claims, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, errors.New("JWT_SECRET not set")
-	}
+// HMAC returns the hex-encoded HMAC-SHA256 of message under secret.
+func HMAC(message, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
 
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtSecret), nil
-	})
+// SignValue appends a keyed signature to value so it can round-trip through
+// an untrusted client, such as in a cookie.
+func SignValue(value, secret string) string {
+	return value + "." + HMAC(value, secret)
+}
 
-	if err != nil {
-		return nil, err
+// VerifySignedValue returns the value from SignValue output, or false if
+// the signature does not match.
+func VerifySignedValue(signed, secret string) (string, bool) {
+	dot := strings.LastIndexByte(signed, '.')
+	if dot < 0 {
+		return "", false
 	}
+	value := signed[:dot]
+	if !hmac.Equal([]byte(HMAC(value, secret)), []byte(signed[dot+1:])) {
+		return "", false
+	}
+	return value, true
+}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+// GenerateToken returns a random URL-safe token with 256 bits of entropy.
+func GenerateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
 
-	return claims, nil
+// HashToken returns the digest under which a random token is stored. Tokens
+// carry enough entropy that an unkeyed hash is sufficient.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
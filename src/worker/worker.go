@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Group runs background workers that share a lifetime and are stopped
+// together on shutdown.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	logger *slog.Logger
+}
+
+func NewGroup(logger *slog.Logger) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logger,
+	}
+}
+
+// Go starts run in its own goroutine. run must return promptly once its
+// context is cancelled.
+func (g *Group) Go(name string, run func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		logger := g.logger.With("worker", name)
+		logger.Info("worker started")
+		run(g.ctx)
+		logger.Info("worker stopped")
+	}()
+}
+
+// Stop cancels every worker and waits for them to return or for ctx to
+// expire, whichever comes first.
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Every returns a worker that calls job once per interval until cancelled.
+func Every(interval time.Duration, logger *slog.Logger, job func(ctx context.Context) error) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(ctx); err != nil && ctx.Err() == nil {
+					logger.Error("background job failed", "error", err)
+				}
+			}
+		}
+	}
+}